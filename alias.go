@@ -0,0 +1,264 @@
+package jrpc2
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DeprecationHeader specifies response header that signals call of deprecated method alias.
+const DeprecationHeader string = "Deprecation"
+
+// MethodVersionSeparator separates method name and method version in versioned method name, (e.g. "user.get@v2").
+const MethodVersionSeparator string = "@"
+
+// alias maps alternative method name to registered method.
+type alias struct {
+	target     string // name of registered method
+	deprecated bool   // alias is kept for deprecation window
+	calls      uint64 // number of calls made through alias
+}
+
+// DeprecationData represents error data of deprecated alias call, wraps original error data.
+type DeprecationData struct {
+	// Deprecated is always true for deprecated alias calls
+	Deprecated bool `json:"deprecated"`
+	// Alias contains called deprecated method name
+	Alias string `json:"alias"`
+	// Method contains method name that should be used instead
+	Method string `json:"method"`
+	// Data contains original error data
+	Data interface{} `json:"data,omitempty"`
+}
+
+// VersionedMethodName returns method name with version suffix, (e.g. "user.get@v2").
+// Call of unversioned name (e.g. "user.get") that is neither registered method nor alias
+// is routed to the latest registered version, see RegisterAlias to pin unversioned name to other version.
+func VersionedMethodName(name, version string) string {
+	return name + MethodVersionSeparator + version
+}
+
+// SplitVersionedMethodName splits versioned method name to method name and version,
+// version is empty for unversioned method name.
+func SplitVersionedMethodName(name string) (string, string) {
+	i := strings.LastIndex(name, MethodVersionSeparator)
+	if i < 0 {
+		return name, ""
+	}
+
+	return name[:i], name[i+len(MethodVersionSeparator):]
+}
+
+// versionLess compares method versions, numeric versions (e.g. "v2" and "v10") are compared as numbers.
+func versionLess(a, b string) bool {
+	x, xerr := strconv.ParseUint(strings.TrimPrefix(a, "v"), 10, 64)
+	y, yerr := strconv.ParseUint(strings.TrimPrefix(b, "v"), 10, 64)
+
+	if xerr == nil && yerr == nil {
+		return x < y
+	}
+
+	return a < b
+}
+
+// latestVersion returns latest registered version of unversioned method name, must be called with service lock held.
+func (s *Service) latestVersion(name string) (string, bool) {
+	var target, latest string
+
+	if _, version := SplitVersionedMethodName(name); version != "" {
+		return "", false
+	}
+
+	for el := range s.methods {
+		base, version := SplitVersionedMethodName(el)
+		if base != name || version == "" {
+			continue
+		}
+
+		if target == "" || versionLess(latest, version) {
+			target, latest = el, version
+		}
+	}
+
+	return target, target != ""
+}
+
+// RegisterAlias makes registered method reachable under alias name.
+// Calls of deprecated alias set Deprecation response header, alias calls are counted.
+// Alias can point to versioned method name, (e.g. "user.get" -> "user.get@v2"), it takes precedence
+// over latest version resolution.
+func (s *Service) RegisterAlias(name, target string, deprecated bool) error {
+	if strings.HasPrefix(strings.ToLower(name), "rpc.") {
+		return fmt.Errorf("alias '%s' cannot match the pattern rpc.*", name)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.methods[target]; !ok {
+		return fmt.Errorf("method '%s' is not registered", target)
+	}
+
+	if _, ok := s.methods[name]; ok {
+		return fmt.Errorf("method '%s' is already registered", name)
+	}
+
+	if s.aliases == nil {
+		s.aliases = make(map[string]*alias)
+	}
+
+	s.aliases[name] = &alias{
+		target:     target,
+		deprecated: deprecated,
+	}
+
+	return nil
+}
+
+// UnregisterAlias removes method alias, returns false when alias is not registered.
+func (s *Service) UnregisterAlias(name string) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.aliases[name]; !ok {
+		return false
+	}
+
+	delete(s.aliases, name)
+
+	return true
+}
+
+// GetAliasCallCounts returns number of calls made through each registered alias.
+func (s *Service) GetAliasCallCounts() map[string]uint64 {
+	s.Lock()
+	defer s.Unlock()
+
+	counts := make(map[string]uint64, len(s.aliases))
+
+	for name, el := range s.aliases {
+		counts[name] = el.calls
+	}
+
+	return counts
+}
+
+// SetDeprecationErrorData enables wrapping of error data into DeprecationData for deprecated alias calls.
+func (s *Service) SetDeprecationErrorData(flag bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.deprecationErrorData = flag
+}
+
+// GetDeprecationErrorData returns true when error data of deprecated alias calls is wrapped into DeprecationData.
+func (s *Service) GetDeprecationErrorData() bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.deprecationErrorData
+}
+
+// resolveAlias returns target method name for alias and counts alias call, other names are returned unchanged.
+func (s *Service) resolveAlias(name string) (target string, deprecated bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	target, el := s.lookupAlias(name)
+	if el == nil {
+		return target, false
+	}
+
+	el.calls++
+
+	return target, el.deprecated
+}
+
+// resolveTarget returns target method name for alias without counting alias call.
+func (s *Service) resolveTarget(name string) string {
+	s.Lock()
+	defer s.Unlock()
+
+	target, _ := s.lookupAlias(name)
+
+	return target
+}
+
+// lookupAlias returns target method name and matched alias, alias is nil for registered methods,
+// names resolved to latest version and unknown names, must be called with service lock held.
+func (s *Service) lookupAlias(name string) (string, *alias) {
+	// registered methods take precedence over aliases
+	if _, ok := s.methods[name]; ok {
+		return name, nil
+	}
+
+	el, ok := s.aliases[name]
+	if !ok {
+		// unversioned name resolves to latest registered version
+		if target, ok := s.latestVersion(name); ok {
+			return target, nil
+		}
+
+		return name, nil
+	}
+
+	return el.target, el
+}
+
+// isDeprecatedAlias checks that name is deprecated alias.
+func (s *Service) isDeprecatedAlias(name string) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.methods[name]; ok {
+		return false
+	}
+
+	el, ok := s.aliases[name]
+
+	return ok && el.deprecated
+}
+
+// setDeprecationHeader sets Deprecation response header when any of called method names is deprecated alias.
+func (s *Service) setDeprecationHeader(r *http.Request, names ...string) *http.Request {
+	for _, name := range names {
+		if s.isDeprecatedAlias(name) {
+			return setResponseHeaders(r, headersFromContext(r.Context()), map[string]string{
+				DeprecationHeader: "true",
+			})
+		}
+	}
+
+	return r
+}
+
+// describeAliases generates OpenRPC method descriptions for aliases, must be called with service lock held.
+func (s *Service) describeAliases() []MethodDescriptor {
+	names := make([]string, 0, len(s.aliases))
+
+	for name := range s.aliases {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	out := make([]MethodDescriptor, 0, len(names))
+
+	for _, name := range names {
+		el := s.aliases[name]
+
+		m, ok := s.methods[el.target]
+		if !ok {
+			continue
+		}
+
+		d := m.describe(name)
+		d.Deprecated = d.Deprecated || el.deprecated
+
+		out = append(out, d)
+	}
+
+	return out
+}
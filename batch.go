@@ -0,0 +1,280 @@
+package jrpc2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// isBatchRequest checks that request body contains JSON array (batch request).
+func isBatchRequest(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+
+	return len(data) > 0 && data[0] == '['
+}
+
+// marshalBatchResponse create a bytes encoded representation of batch response objects.
+func marshalBatchResponse(respObjs []*ResponseObject) []byte {
+	parts := make([][]byte, 0, len(respObjs))
+
+	// marshal each response object separately, keeps per-object error fallback
+	for _, el := range respObjs {
+		parts = append(parts, el.Marshal())
+	}
+
+	out := make([]byte, 0)
+
+	out = append(out, '[')
+	out = append(out, bytes.Join(parts, []byte(","))...)
+	out = append(out, ']')
+
+	return out
+}
+
+// batchElement represents decoded element of batch request.
+type batchElement struct {
+	req *RequestObject   // decoded request object
+	err error            // decoding error, request object is not usable when defined
+	id  *json.RawMessage // request ID recovered from element that can not be decoded
+}
+
+// decodeBatchElement decodes single element of batch request.
+func decodeBatchElement(data json.RawMessage) batchElement {
+	// create placeholder for request object
+	reqObj := new(RequestObject)
+
+	// decode batch element
+	if err := json.Unmarshal(data, reqObj); err != nil {
+		return batchElement{err: err, id: recoverRequestID(data)}
+	}
+
+	return batchElement{req: reqObj}
+}
+
+// method returns method name of batch element, empty string when it can not be decoded.
+func (el batchElement) method() string {
+	if el.err != nil {
+		return ""
+	}
+
+	return el.req.Method
+}
+
+// callBatchElement processes single element of batch request, returns nil for notifications.
+func (s *Service) callBatchElement(r *http.Request, el batchElement) *ResponseObject {
+	// create default response object
+	respObj := DefaultResponseObject()
+
+	// set pointer to HTTP request object
+	respObj.r = r
+
+	// batch element can not be decoded
+	if err := el.err; err != nil {
+		// set response ID when it is readable
+		respObj.ID = el.id
+
+		// define Error object
+		respObj.Error = &ErrorObject{
+			Code:    InvalidRequestCode,
+			Message: InvalidRequestMessage,
+			Data:    err.Error(),
+		}
+
+		// invalid data type for method
+		if v, ok := err.(*json.UnmarshalTypeError); ok && v.Field == "method" {
+			respObj.Error = &ErrorObject{
+				Code:    InvalidMethodCode,
+				Message: InvalidMethodMessage,
+				Data:    "method data type must be string",
+			}
+		}
+
+		return respObj
+	}
+
+	reqObj := el.req
+
+	// validate JSON-RPC 2.0 request version member
+	if ok := respObj.ValidateJSONRPCVersionNumber(r, reqObj.Jsonrpc); !ok {
+		return respObj
+	}
+
+	// parse ID member
+	if _, errObj := ConvertIDtoString(reqObj.ID); errObj != nil {
+		// define Error object
+		respObj.Error = errObj
+
+		return respObj
+	}
+
+	// set response ID
+	respObj.ID = reqObj.ID
+
+	// prepare parameters object for named method
+	paramsObj := ParametersObject{
+		id: reqObj.ID,
+
+		method: reqObj.Method,
+		params: reqObj.Params,
+
+		r: r,
+	}
+
+	// invoke named method with the provided parameters
+	result, errObj := s.Call(reqObj.Method, paramsObj)
+
+	// notification does not send responses to client
+	if reqObj.ID == nil {
+		return nil
+	}
+
+	respObj.Result = result
+	respObj.Error = errObj
+
+	return respObj
+}
+
+// callBatch invokes all elements of batch request, returns responses for non-notification elements.
+// Elements are invoked concurrently when batch concurrency is configured, except sequential methods
+// which are invoked one after another in request order.
+func (s *Service) callBatch(r *http.Request, batch []batchElement) []*ResponseObject {
+	results := make([]*ResponseObject, len(batch))
+
+	if concurrency := s.GetBatchConcurrency(); concurrency < 2 {
+		// invoke batch elements one by one
+		for i, el := range batch {
+			results[i] = s.callBatchElement(r, el)
+		}
+	} else {
+		var wg sync.WaitGroup
+
+		// worker goroutines are not owned by HTTP server, aborted HTTP handler is reported as internal error
+		wr := setAbortHandler(r, false)
+
+		// limits number of concurrently invoked elements
+		sem := make(chan struct{}, concurrency)
+
+		// split batch to sequential and concurrent elements
+		sequential := make([]int, 0)
+		concurrent := make([]int, 0, len(batch))
+
+		for i, el := range batch {
+			// aliases are resolved, sequential setting of called name or target method applies
+			if name := el.method(); s.isSequentialMethod(name) || s.isSequentialMethod(s.resolveTarget(name)) {
+				sequential = append(sequential, i)
+			} else {
+				concurrent = append(concurrent, i)
+			}
+		}
+
+		// sequential elements share single goroutine to keep their order
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for _, i := range sequential {
+				sem <- struct{}{}
+				results[i] = s.callBatchElement(wr, batch[i])
+				<-sem
+			}
+		}()
+
+		for _, i := range concurrent {
+			wg.Add(1)
+
+			sem <- struct{}{}
+
+			go func(i int) {
+				defer wg.Done()
+
+				results[i] = s.callBatchElement(wr, batch[i])
+				<-sem
+			}(i)
+		}
+
+		wg.Wait()
+	}
+
+	respObjs := make([]*ResponseObject, 0, len(batch))
+
+	// skip notifications, keep request order
+	for _, el := range results {
+		if el != nil {
+			respObjs = append(respObjs, el)
+		}
+	}
+
+	return respObjs
+}
+
+// decodeBatch decodes and validates batch request, returns error object for malformed, empty or oversized batch.
+// Each batch element is decoded once, element decoding errors are reported in element responses.
+func (s *Service) decodeBatch(data []byte) ([]batchElement, *ErrorObject) {
+	// create placeholder for batch elements
+	raw := make([]json.RawMessage, 0)
+
+	// decode batch request
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, &ErrorObject{
+			Code:    ParseErrorCode,
+			Message: ParseErrorMessage,
+			Data:    err.Error(),
+		}
+	}
+
+	// empty array is not a valid batch
+	if len(raw) == 0 {
+		return nil, &ErrorObject{
+			Code:    InvalidRequestCode,
+			Message: InvalidRequestMessage,
+			Data:    "batch request must not be empty",
+		}
+	}
+
+	// batch exceeds configured size limit
+	if limit := s.GetBatchSizeLimit(); limit > 0 && len(raw) > limit {
+		return nil, &ErrorObject{
+			Code:    InvalidRequestCode,
+			Message: InvalidRequestMessage,
+			Data:    fmt.Sprintf("batch request must not contain more than %d elements", limit),
+		}
+	}
+
+	batch := make([]batchElement, 0, len(raw))
+	for _, el := range raw {
+		batch = append(batch, decodeBatchElement(el))
+	}
+
+	return batch, nil
+}
+
+// serveBatch handles batch request, writes batch response to HTTP response writer.
+func (s *Service) serveBatch(w http.ResponseWriter, respObj *ResponseObject, data []byte) {
+	// decode and validate batch request
+	batch, errObj := s.decodeBatch(data)
+	if errObj != nil {
+		// define Error object
+		respObj.Error = errObj
+
+		// write response to HTTP writer
+		s.WriteResponse(w, respObj)
+
+		// end request processing
+		return
+	}
+
+	// invoke batch elements
+	respObjs := s.callBatch(respObj.r, batch)
+
+	// collect method names of batch elements
+	names := make([]string, 0, len(batch))
+	for _, el := range batch {
+		names = append(names, el.method())
+	}
+
+	// write response to HTTP writer, deprecated alias call in any element is signalled in response headers
+	s.WriteBatchResponse(w, s.setDeprecationHeader(respObj.r, names...), respObjs)
+}
@@ -1,7 +1,10 @@
 package jrpc2
 
 import (
+	"context"
+	"fmt"
 	"strings"
+	"time"
 )
 
 // Call invokes the named method with the provided parameters.
@@ -15,9 +18,62 @@ func (s *Service) Call(name string, data ParametersObject) (interface{}, *ErrorO
 		}
 	}
 
+	// resolve method alias
+	target, deprecated := s.resolveAlias(name)
+
+	// lookup method
+	f, errObj := s.lookup(target)
+	if errObj != nil {
+		return nil, errObj
+	}
+
+	// method execution timeout
+	timeout := f.timeout
+	if timeout == 0 {
+		timeout = s.GetMethodTimeout()
+	}
+
+	// prepare method context
+	ctx, cancel := methodContext(data, timeout)
+	defer cancel()
+
+	// update parameters object with method context
+	data = data.withContext(ctx)
+
+	// invoke method wrapped with middleware chain
+	result, errObj := s.handleRecover(s.chain(name, target, s.methodHandler(f, timeout)), data)
+
+	// signal deprecated alias call in error data
+	if deprecated && errObj != nil && s.GetDeprecationErrorData() {
+		errObj = &ErrorObject{
+			Code:    errObj.Code,
+			Message: errObj.Message,
+			Data: DeprecationData{
+				Deprecated: true,
+				Alias:      name,
+				Method:     target,
+				Data:       errObj.Data,
+			},
+		}
+	}
+
+	return result, errObj
+}
+
+// lookup finds callable method by name.
+func (s *Service) lookup(name string) (method, *ErrorObject) {
+	// reserved service discovery method
+	if name == DiscoverMethodName && !s.proxy {
+		return method{
+			Method: func(_ ParametersObject) (interface{}, *ErrorObject) {
+				return s.GetOpenRPCDocument(), nil
+			},
+		}, nil
+	}
+
 	// check that request method member is not rpc-internal method
 	if strings.HasPrefix(strings.ToLower(name), "rpc.") && !s.proxy {
-		return nil, &ErrorObject{
+		return method{}, &ErrorObject{
 			Code:    InvalidRequestCode,
 			Message: InvalidRequestMessage,
 			Data:    "method cannot match the pattern rpc.*",
@@ -30,22 +86,97 @@ func (s *Service) Call(name string, data ParametersObject) (interface{}, *ErrorO
 	}
 
 	// lookup method inside methods map
-	f, ok := s.methods[name]
+	f, ok := s.getMethod(name)
 	if !ok {
-		return nil, &ErrorObject{
+		return method{}, &ErrorObject{
 			Code:    MethodNotFoundCode,
 			Message: MethodNotFoundMessage,
 		}
 	}
 
 	// noncallable named method
-	if f.Method == nil {
-		return nil, &ErrorObject{
+	if f.Method == nil && f.ContextMethod == nil {
+		return method{}, &ErrorObject{
 			Code:    InternalErrorCode,
 			Message: InternalErrorMessage,
 			Data:    "unable to call provided method",
 		}
 	}
 
-	return f.Method(data)
+	return f, nil
+}
+
+// methodHandler creates handler that validates params and invokes method with execution timeout.
+func (s *Service) methodHandler(f method, timeout time.Duration) Handler {
+	return func(data ParametersObject) (interface{}, *ErrorObject) {
+		// validate params against method JSON Schema
+		if f.validator != nil {
+			if violations := f.validator.Validate(data.GetRawJSONParams()); len(violations) > 0 {
+				return nil, &ErrorObject{
+					Code:    InvalidParamsCode,
+					Message: InvalidParamsMessage,
+					Data:    violations,
+				}
+			}
+		}
+
+		// invoke without timeout enforcement
+		if timeout <= 0 {
+			return f.invoke(data)
+		}
+
+		type callResult struct {
+			result interface{}
+			errObj *ErrorObject
+		}
+
+		ctx := data.GetContext()
+
+		// method keeps running in background after timeout, it should watch method context
+		done := make(chan callResult, 1)
+
+		go func() {
+			// worker goroutine is not owned by HTTP server, aborted HTTP handler is reported as internal error
+			result, errObj := s.handleRecover(f.invoke, data.withContext(contextWithAbortHandlerFlag(ctx, false)))
+			done <- callResult{result, errObj}
+		}()
+
+		var res callResult
+
+		select {
+		case res = <-done:
+		case <-ctx.Done():
+		}
+
+		// execution timeout fired, method result is discarded
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, &ErrorObject{
+				Code:    MethodTimeoutCode,
+				Message: MethodTimeoutMessage,
+				Data:    fmt.Sprintf("method execution exceeded %s", timeout),
+			}
+		}
+
+		// request was cancelled, client went away
+		if ctx.Err() != nil {
+			return nil, &ErrorObject{
+				Code:    InternalErrorCode,
+				Message: InternalErrorMessage,
+				Data:    ctx.Err().Error(),
+			}
+		}
+
+		return res.result, res.errObj
+	}
+}
+
+// methodContext derives method context from request context, applies method timeout.
+func methodContext(data ParametersObject, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx := data.GetContext()
+
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+
+	return context.WithCancel(ctx)
 }
@@ -7,7 +7,10 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 	"time"
+
+	"golang.org/x/net/http2"
 )
 
 // GetConfig returns default JSON-RPC Call config.
@@ -94,6 +97,50 @@ func (c *Config) DisableCompression(t bool) {
 	c.disableCompression = t
 }
 
+// EnableHTTP2 switches client to HTTP/2, concurrent calls are multiplexed over single connection.
+// HTTP/2 without TLS (h2c) is used for plain HTTP URLs and Unix-Socket, server must have h2c enabled.
+func (c *Config) EnableHTTP2(t bool) {
+	if !t {
+		transport := &http.Transport{
+			DisableCompression: c.disableCompression,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: c.insecureSkipVerify, // nolint: gosec
+			},
+		}
+
+		if c.socketPath != nil {
+			transport.DialContext = func(_ context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial("unix", *c.socketPath)
+			}
+		}
+
+		c.httpClient.Transport = transport
+
+		return
+	}
+
+	transport := &http2.Transport{
+		DisableCompression: c.disableCompression,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: c.insecureSkipVerify, // nolint: gosec
+		},
+	}
+
+	// HTTP/2 without TLS (h2c)
+	if c.socketPath != nil || strings.HasPrefix(c.uri, "http://") {
+		transport.AllowHTTP = true
+		transport.DialTLS = func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			if c.socketPath != nil {
+				return net.Dial("unix", *c.socketPath)
+			}
+
+			return net.Dial(network, addr)
+		}
+	}
+
+	c.httpClient.Transport = transport
+}
+
 // SkipSSLCertificateCheck disables server's certificate chain and host name check, INSECURE!.
 func (c *Config) SkipSSLCertificateCheck(t bool) {
 	c.insecureSkipVerify = t
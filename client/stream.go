@@ -0,0 +1,408 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Framing defines how JSON-RPC messages are delimited in byte stream.
+type Framing int
+
+const (
+	// NewlineFraming delimits messages with newline character, (newline-delimited JSON).
+	NewlineFraming Framing = iota
+	// ContentLengthFraming prefixes messages with Content-Length header, (LSP-style base protocol).
+	ContentLengthFraming
+)
+
+// ErrClosed is returned for calls on closed stream client.
+var ErrClosed = errors.New("stream is closed")
+
+// StreamClient defines JSON-RPC client over byte stream (stdio of subprocess, TCP or unix socket connection),
+// concurrent calls share single stream and are matched to responses by ID.
+type StreamClient struct {
+	mu sync.Mutex // serializes writes and guards fields below
+
+	rwc     io.ReadWriteCloser
+	framing Framing
+	r       *bufio.Reader
+
+	// Pending calls mapped by request ID
+	pending map[string]chan *ResponseObject
+	// Server notifications handler
+	notify func(method string, params json.RawMessage)
+	// Received notifications, waiting for notifications handler
+	queue []notificationObject
+	// Signals that notifications are queued
+	queued chan struct{}
+	// Context response timeout
+	timeout time.Duration
+
+	closed bool
+	err    error
+	done   chan struct{}
+
+	cmd *exec.Cmd // spawned subprocess, when defined
+}
+
+// notificationObject represents server notification (request object without ID).
+type notificationObject struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	ID     *string         `json:"id"`
+}
+
+// NewStreamClient creates JSON-RPC client over provided byte stream.
+func NewStreamClient(rwc io.ReadWriteCloser, framing Framing) *StreamClient {
+	c := &StreamClient{
+		rwc:     rwc,
+		framing: framing,
+		r:       bufio.NewReader(rwc),
+		pending: make(map[string]chan *ResponseObject),
+		timeout: 90 * time.Second,
+		queued:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	go c.readLoop()
+	go c.notifyLoop()
+
+	return c
+}
+
+// processStream joins subprocess stdin and stdout into single stream.
+type processStream struct {
+	io.ReadCloser  // subprocess stdout
+	io.WriteCloser // subprocess stdin
+}
+
+// Close closes subprocess stdin, subprocess is expected to exit on EOF.
+func (p processStream) Close() error {
+	return p.WriteCloser.Close()
+}
+
+// StartProcess spawns subprocess and creates JSON-RPC client over its standard input and standard output,
+// subprocess standard error is forwarded to current process when it is not defined.
+func StartProcess(cmd *exec.Cmd, framing Framing) (*StreamClient, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, NewInternalError(ErrorPrefix, err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, NewInternalError(ErrorPrefix, err)
+	}
+
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+
+	if err = cmd.Start(); err != nil {
+		return nil, NewInternalError(ErrorPrefix, err)
+	}
+
+	c := NewStreamClient(processStream{stdout, stdin}, framing)
+	c.cmd = cmd
+
+	return c, nil
+}
+
+// SetTimeout sets request timeout time in seconds.
+func (c *StreamClient) SetTimeout(t int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.timeout = time.Duration(t) * time.Second
+}
+
+// SetNotificationHandler defines function that receives server notifications.
+// Handler is invoked on its own goroutine in order of arrival, it can make calls on the same client,
+// notifications are queued while handler is running.
+func (c *StreamClient) SetNotificationHandler(f func(method string, params json.RawMessage)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notify = f
+}
+
+// readMessage reads single framed message.
+func (c *StreamClient) readMessage() ([]byte, error) {
+	if c.framing == ContentLengthFraming {
+		header, err := textproto.NewReader(c.r).ReadMIMEHeader()
+		if err != nil {
+			return nil, err
+		}
+
+		length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("invalid Content-Length header '%s'", header.Get("Content-Length"))
+		}
+
+		data := make([]byte, length)
+
+		if _, err = io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+
+		return data, nil
+	}
+
+	for {
+		line, err := c.r.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// writeMessage writes single framed message, must be called with lock held.
+func (c *StreamClient) writeMessage(data []byte) error {
+	var err error
+
+	if c.framing == ContentLengthFraming {
+		_, err = fmt.Fprintf(c.rwc, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	} else {
+		_, err = c.rwc.Write(append(data, '\n'))
+	}
+
+	return err
+}
+
+// readLoop dispatches responses to pending calls and queues notifications for notification handler.
+// Error response with null ID (e.g. parse error) is passed to all pending calls.
+func (c *StreamClient) readLoop() {
+	var err error
+
+	for {
+		var data []byte
+
+		data, err = c.readMessage()
+		if err != nil {
+			break
+		}
+
+		// server notification
+		notification := new(notificationObject)
+		if json.Unmarshal(data, notification) == nil && notification.Method != "" && notification.ID == nil {
+			c.mu.Lock()
+			c.queue = append(c.queue, *notification)
+			c.mu.Unlock()
+
+			// wake up notifications handler
+			select {
+			case c.queued <- struct{}{}:
+			default:
+			}
+
+			continue
+		}
+
+		respObj := new(ResponseObject)
+
+		// skip malformed messages
+		if json.Unmarshal(data, respObj) != nil {
+			continue
+		}
+
+		c.mu.Lock()
+
+		// error response without ID can not be matched to call, all waiting calls fail with it
+		if respObj.ID == "" && respObj.Error != nil {
+			for id, ch := range c.pending {
+				delete(c.pending, id)
+				ch <- respObj
+			}
+		}
+
+		ch, ok := c.pending[respObj.ID]
+		delete(c.pending, respObj.ID)
+		c.mu.Unlock()
+
+		if ok {
+			ch <- respObj
+		}
+	}
+
+	// stream ended, fail pending calls
+	c.mu.Lock()
+	c.closed = true
+	c.err = err
+	c.mu.Unlock()
+
+	close(c.done)
+}
+
+// notifyLoop passes queued notifications to notifications handler, responses are read while handler is running.
+func (c *StreamClient) notifyLoop() {
+	for {
+		select {
+		case <-c.queued:
+		case <-c.done:
+			// deliver notifications received before stream ended
+			c.dispatchNotifications()
+
+			return
+		}
+
+		c.dispatchNotifications()
+	}
+}
+
+// dispatchNotifications passes all queued notifications to notifications handler.
+func (c *StreamClient) dispatchNotifications() {
+	for {
+		c.mu.Lock()
+
+		if len(c.queue) == 0 {
+			c.mu.Unlock()
+
+			return
+		}
+
+		notification := c.queue[0]
+		c.queue = c.queue[1:]
+		f := c.notify
+
+		c.mu.Unlock()
+
+		if f != nil {
+			f(notification.Method, notification.Params)
+		}
+	}
+}
+
+// Call wraps JSON-RPC client call.
+func (c *StreamClient) Call(method string, params json.RawMessage) (json.RawMessage, error) {
+	c.mu.Lock()
+	timeout := c.timeout
+	c.mu.Unlock()
+
+	// set timeout
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return c.CallContext(ctx, method, params)
+}
+
+// CallContext wraps JSON-RPC client call, call is abandoned when provided context is done.
+func (c *StreamClient) CallContext(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	// prepare request object
+	reqObj := getRequestObject(method, params)
+
+	// convert request object to bytes
+	reqData, err := json.Marshal(reqObj)
+	if err != nil {
+		return nil, NewInternalError(ErrorPrefix, err)
+	}
+
+	ch := make(chan *ResponseObject, 1)
+
+	c.mu.Lock()
+
+	if c.closed {
+		c.mu.Unlock()
+
+		return nil, NewInternalError(ErrorPrefix, ErrClosed)
+	}
+
+	c.pending[reqObj.ID] = ch
+
+	err = c.writeMessage(reqData)
+	if err != nil {
+		delete(c.pending, reqObj.ID)
+	}
+
+	c.mu.Unlock()
+
+	if err != nil {
+		return nil, NewInternalError(ErrorPrefix, err)
+	}
+
+	var respObj *ResponseObject
+
+	select {
+	case respObj = <-ch:
+	case <-c.done:
+		return nil, NewInternalError(ErrorPrefix, ErrClosed)
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, reqObj.ID)
+		c.mu.Unlock()
+
+		return nil, NewInternalError(ErrorPrefix, ctx.Err())
+	}
+
+	// validate request/response Jsonrpc protocol versions
+	if reqObj.Jsonrpc != respObj.Jsonrpc {
+		return nil, NewInternalError(ErrorPrefix, nil).SetProtocolVersions(respObj.Jsonrpc, reqObj.Jsonrpc)
+	}
+
+	// check response error
+	if respObj.Error != nil {
+		return nil, respObj.Error
+	}
+
+	return respObj.Result, nil
+}
+
+// Notify sends JSON-RPC notification, server does not respond to it.
+func (c *StreamClient) Notify(method string, params json.RawMessage) error {
+	// prepare request object without ID
+	reqData, err := json.Marshal(struct {
+		Jsonrpc string          `json:"jsonrpc"`
+		Method  string          `json:"method"`
+		Params  json.RawMessage `json:"params,omitempty"`
+	}{
+		Jsonrpc: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return NewInternalError(ErrorPrefix, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return NewInternalError(ErrorPrefix, ErrClosed)
+	}
+
+	if err = c.writeMessage(reqData); err != nil {
+		return NewInternalError(ErrorPrefix, err)
+	}
+
+	return nil
+}
+
+// Close closes stream, for subprocess closes its standard input and waits for it to exit.
+func (c *StreamClient) Close() error {
+	err := c.rwc.Close()
+
+	if c.cmd != nil {
+		// subprocess exits after its stdin is closed
+		<-c.done
+
+		if werr := c.cmd.Wait(); werr != nil && err == nil {
+			err = werr
+		}
+	}
+
+	return err
+}
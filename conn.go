@@ -0,0 +1,238 @@
+package jrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrConnClosed is returned when writing to closed connection.
+var ErrConnClosed = errors.New("connection is closed")
+
+// Conn represents persistent client connection (e.g. WebSocket), it allows server to send notifications to client.
+// Connection of the current request is available from method parameters object, see ParametersObject.GetConn.
+type Conn struct {
+	mu sync.Mutex // serializes writes
+
+	ctx    context.Context    // cancelled when connection is closed
+	cancel context.CancelFunc // cancels connection context
+
+	write func(data []byte) error // writes single message to client
+	close func() error            // closes underlying transport
+
+	closeOnce sync.Once // closes connection once, without waiting for blocked writes
+	closeErr  error     // result of closing underlying transport
+
+	sem chan struct{} // limits number of concurrently processed messages
+
+	flightMu sync.Mutex    // guards in-flight messages counter
+	inflight int           // number of messages being processed
+	draining bool          // flags that new messages are discarded
+	idle     chan struct{} // closed when connection is draining and no messages are processed
+
+	subMu sync.Mutex                    // guards subscriptions
+	subs  map[string]context.CancelFunc // active subscriptions, mapped by subscription ID
+}
+
+// newConn creates connection with provided write and close functions, connection context is derived from ctx.
+// Limit defines maximum number of concurrently processed messages.
+func newConn(ctx context.Context, write func(data []byte) error, close func() error, limit int) *Conn {
+	c := &Conn{
+		write: write,
+		close: close,
+		sem:   make(chan struct{}, limit),
+	}
+
+	c.ctx, c.cancel = context.WithCancel(ctx)
+
+	return c
+}
+
+// Context returns connection context, it is cancelled when connection is closed.
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// Write sends encoded message to client, safe for concurrent use.
+func (c *Conn) Write(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ctx.Err() != nil {
+		return ErrConnClosed
+	}
+
+	return c.write(data)
+}
+
+// Notify sends JSON-RPC 2.0 notification (request object without ID) to client.
+func (c *Conn) Notify(method string, params interface{}) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(RequestObject{
+		Jsonrpc: JSONRPCVersion,
+		Method:  method,
+		Params:  b,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.Write(data)
+}
+
+// Close closes connection and cancels connection context, in-flight method calls are cancelled.
+// Close does not wait for pending writes, closing underlying transport unblocks them.
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		c.cancel()
+		c.closeErr = c.close()
+	})
+
+	return c.closeErr
+}
+
+// dispatch processes message on its own goroutine, it waits while concurrency limit is reached.
+// Returns false when connection is closed or draining and message is discarded.
+func (c *Conn) dispatch(f func()) bool {
+	select {
+	case c.sem <- struct{}{}:
+	case <-c.ctx.Done():
+		return false
+	}
+
+	c.flightMu.Lock()
+	defer c.flightMu.Unlock()
+
+	if c.draining {
+		<-c.sem
+
+		return false
+	}
+
+	c.inflight++
+
+	go func() {
+		defer c.finish()
+
+		f()
+	}()
+
+	return true
+}
+
+// finish marks message as processed.
+func (c *Conn) finish() {
+	<-c.sem
+
+	c.flightMu.Lock()
+	defer c.flightMu.Unlock()
+
+	c.inflight--
+
+	if c.draining && c.inflight == 0 {
+		close(c.idle)
+	}
+}
+
+// drain stops processing of new messages, returned channel is closed when in-flight messages are processed.
+func (c *Conn) drain() <-chan struct{} {
+	c.flightMu.Lock()
+	defer c.flightMu.Unlock()
+
+	if !c.draining {
+		c.draining = true
+		c.idle = make(chan struct{})
+
+		if c.inflight == 0 {
+			close(c.idle)
+		}
+	}
+
+	return c.idle
+}
+
+// handleConnMessage processes message received over persistent connection, writes response to connection,
+// subscriptions started by message are activated after response is written.
+func (s *Service) handleConnMessage(c *Conn, r *http.Request, data []byte) {
+	pending := new(pendingSubscriptions)
+
+	// method context carries pending subscriptions
+	r = r.WithContext(contextWithPendingSubscriptions(r.Context(), pending))
+
+	if resp := s.handleMessage(r, data); resp != nil {
+		_ = c.Write(resp)
+	}
+
+	pending.activate()
+}
+
+// GetConn returns persistent client connection of the request, nil for plain HTTP requests.
+func (p ParametersObject) GetConn() *Conn {
+	return connFromContext(p.GetContext())
+}
+
+// trackConn adds connection to the list of service connections that are closed on shutdown.
+// Returns false when service was shut down.
+func (s *Service) trackConn(c *Conn) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.shutdown {
+		return false
+	}
+
+	if s.conns == nil {
+		s.conns = make(map[*Conn]struct{})
+	}
+
+	s.conns[c] = struct{}{}
+
+	return true
+}
+
+// untrackConn removes connection from the list of service connections.
+func (s *Service) untrackConn(c *Conn) {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.conns, c)
+}
+
+// closeConns closes all service connections after their in-flight messages are processed,
+// connections are closed immediately when provided context is done.
+func (s *Service) closeConns(ctx context.Context) error {
+	s.Lock()
+	conns := make([]*Conn, 0, len(s.conns))
+
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.Unlock()
+
+	var wg sync.WaitGroup
+
+	for _, c := range conns {
+		wg.Add(1)
+
+		go func(c *Conn) {
+			defer wg.Done()
+
+			select {
+			case <-c.drain():
+			case <-ctx.Done():
+			}
+
+			_ = c.Close()
+		}(c)
+	}
+
+	wg.Wait()
+
+	return ctx.Err()
+}
@@ -1,11 +1,30 @@
 package jrpc2
 
+import (
+	"time"
+)
+
 // JSONRPCVersion specifies the version of the JSON-RPC protocol.
 const JSONRPCVersion string = "2.0"
 
+// OpenRPCVersion specifies the version of the OpenRPC specification used by service discovery.
+const OpenRPCVersion string = "1.2.6"
+
+// DiscoverMethodName specifies reserved service discovery method name.
+const DiscoverMethodName string = "rpc.discover"
+
+// DefaultHTTPVersion specifies HTTP protocol version accepted by default.
+const DefaultHTTPVersion string = "HTTP/1.1"
+
 // DefaultUnixSocketMode specifies default permissions for unix socket.
 const DefaultUnixSocketMode = 0777
 
+// DefaultShutdownTimeout specifies default time to wait for active requests on context-driven shutdown.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// DefaultConnConcurrency specifies default maximum number of concurrently processed messages per persistent connection.
+const DefaultConnConcurrency = 64
+
 // Error codes.
 const (
 	ParseErrorCode     int = -32700
@@ -16,6 +35,8 @@ const (
 	NotImplementedCode int = -32000
 	InvalidIDCode      int = -32001
 	InvalidMethodCode  int = -32002
+	MethodTimeoutCode  int = -32003
+	HookErrorCode      int = -32004
 )
 
 // Error message.
@@ -28,4 +49,6 @@ const (
 	NotImplementedMessage string = "Not implemented"
 	InvalidIDMessage      string = "Invalid ID"
 	InvalidMethodMessage  string = "Invalid method"
+	MethodTimeoutMessage  string = "Method timeout"
+	HookErrorMessage      string = "Hook error"
 )
@@ -20,6 +20,9 @@ const (
 	ctxKeyNotificationFlag
 	ctxKeyHTTPStatusCode
 	ctxKeyHeaders
+	ctxKeyConn
+	ctxKeyPendingSubscriptions
+	ctxKeyAbortHandlerFlag
 )
 
 func contextWithBehindReverseProxyFlag(ctx context.Context, flag bool) context.Context {
@@ -226,6 +229,57 @@ func headersFromContext(ctx context.Context) map[string]string {
 	}
 }
 
+func contextWithConn(ctx context.Context, c *Conn) context.Context {
+	return context.WithValue(ctx, ctxKeyConn, c)
+}
+
+func connFromContext(ctx context.Context) *Conn {
+	if ctx == nil {
+		return nil
+	}
+
+	switch v := ctx.Value(ctxKeyConn).(type) {
+	case *Conn:
+		return v
+	default:
+		return nil
+	}
+}
+
+func contextWithPendingSubscriptions(ctx context.Context, p *pendingSubscriptions) context.Context {
+	return context.WithValue(ctx, ctxKeyPendingSubscriptions, p)
+}
+
+func pendingSubscriptionsFromContext(ctx context.Context) *pendingSubscriptions {
+	if ctx == nil {
+		return nil
+	}
+
+	switch v := ctx.Value(ctxKeyPendingSubscriptions).(type) {
+	case *pendingSubscriptions:
+		return v
+	default:
+		return nil
+	}
+}
+
+func contextWithAbortHandlerFlag(ctx context.Context, flag bool) context.Context {
+	return context.WithValue(ctx, ctxKeyAbortHandlerFlag, flag)
+}
+
+func abortHandlerFlagFromContext(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+
+	switch v := ctx.Value(ctxKeyAbortHandlerFlag).(type) {
+	case bool:
+		return v
+	default:
+		return false
+	}
+}
+
 func (s *Service) setRequestContextEarly(r *http.Request) *http.Request {
 	ctx := r.Context()
 
@@ -261,6 +315,16 @@ func setHTTPStatusCode(r *http.Request, status int) *http.Request {
 	return r.WithContext(ctx)
 }
 
+// setAbortHandler marks that request is processed on HTTP handler goroutine owned by HTTP server,
+// only there method panic can abort HTTP handler.
+func setAbortHandler(r *http.Request, flag bool) *http.Request {
+	ctx := r.Context()
+
+	ctx = contextWithAbortHandlerFlag(ctx, flag)
+
+	return r.WithContext(ctx)
+}
+
 func setNotification(r *http.Request) *http.Request {
 	ctx := r.Context()
 
@@ -0,0 +1,124 @@
+package jrpc2
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// newEndpoint defines a new service instance without socket, network address and certificate.
+func newEndpoint(route string, behindReverseProxy bool) *Service {
+	return &Service{
+		route: route,
+
+		behindReverseProxy: behindReverseProxy,
+
+		headers: make(map[string]string),
+		methods: make(map[string]method),
+		auth:    nil,
+
+		proxy: false,
+
+		info: defaultOpenRPCInfo(),
+
+		shutdownTimeout: DefaultShutdownTimeout,
+		hookErrorCode:   HookErrorCode,
+
+		req: func(r *http.Request, data []byte) error {
+			return nil
+		},
+		resp: func(r *http.Request, data []byte) error {
+			return nil
+		},
+	}
+}
+
+// CreateHandler defines a new service instance meant to be used only as HTTP handler (http.Handler),
+// without unix socket, network address and certificate, it can not be started on its own.
+//
+// Route is used by HTTPHandler and Mount to register service on HTTP request multiplexer, Service.ServeHTTP itself
+// serves any URL path. When service is mounted under path prefix with http.StripPrefix, route must not contain
+// that prefix, and request URL path seen by service (e.g. in SetRouteMaxRequestSize) is the path without prefix.
+func CreateHandler(route string) *Service {
+	return newEndpoint(normalizeRoute(route), false)
+}
+
+// Mux describes HTTP request multiplexer that service can be mounted on, (e.g. http.ServeMux).
+type Mux interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// HTTPHandler returns HTTP handler that serves service route and additional endpoints routes.
+func (s *Service) HTTPHandler() http.Handler {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.handler()
+}
+
+// handler creates HTTP request multiplexer for service route and additional endpoints routes.
+func (s *Service) handler() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	s.mount(mux)
+
+	return mux
+}
+
+// Mount registers service route and additional endpoints routes on provided HTTP request multiplexer.
+func (s *Service) Mount(mux Mux) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.mount(mux)
+}
+
+// mount registers service route and additional endpoints routes on HTTP request multiplexer.
+func (s *Service) mount(mux Mux) {
+	mux.Handle(s.route, s)
+
+	// additional endpoints
+	for route, endpoint := range s.endpoints {
+		mux.Handle(route, endpoint)
+	}
+}
+
+// AddEndpoint defines additional JSON-RPC 2.0 endpoint served on provided route by the same server (listener).
+// Endpoint is an independent service instance with its own methods, authorization, headers, hooks, middleware,
+// method timeouts, batch and request size limits, these are not inherited and must be configured on endpoint.
+// Endpoint inherits reverse proxy flag and accepted HTTP versions (unless set on endpoint), HTTP server settings
+// (timeouts, h2c) are those of parent service. Endpoint is shut down with parent service.
+// Endpoints must be added before service is started.
+func (s *Service) AddEndpoint(route string) (*Service, error) {
+	// route is normalized same as SetRoute
+	route = normalizeRoute(route)
+
+	s.Lock()
+	defer s.Unlock()
+
+	if route == s.route {
+		return nil, fmt.Errorf("route '%s' is already served", route)
+	}
+
+	if _, ok := s.endpoints[route]; ok {
+		return nil, fmt.Errorf("route '%s' is already served", route)
+	}
+
+	if s.endpoints == nil {
+		s.endpoints = make(map[string]*Service)
+	}
+
+	endpoint := newEndpoint(route, s.behindReverseProxy)
+	endpoint.parent = s
+
+	s.endpoints[route] = endpoint
+
+	return endpoint, nil
+}
+
+// GetEndpoint returns endpoint served on provided route, nil when route is not defined.
+func (s *Service) GetEndpoint(route string) *Service {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.endpoints[route]
+}
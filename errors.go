@@ -1,5 +1,9 @@
 package jrpc2
 
+import (
+	"fmt"
+)
+
 // ErrorObject represents a response error object.
 type ErrorObject struct {
 	// Code indicates the error type that occurred
@@ -9,3 +13,8 @@ type ErrorObject struct {
 	// Data can contain additional information about the error
 	Data interface{} `json:"data,omitempty"`
 }
+
+// Error defines method to satisfy default error interface.
+func (e *ErrorObject) Error() string {
+	return fmt.Sprintf("%d, %s", e.Code, e.Message)
+}
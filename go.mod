@@ -5,5 +5,8 @@ go 1.13
 replace github.com/s3rj1k/jrpc2/client => ./client
 
 require (
+	github.com/gorilla/websocket v1.4.2
+	github.com/s3rj1k/jrpc2/client v0.0.0-00010101000000-000000000000
 	golang.org/x/crypto v0.0.0-20191011191535-87dc89f01550
+	golang.org/x/net v0.0.0-20191014212845-da9a3fd4c582
 )
@@ -1,19 +1,159 @@
 package jrpc2
 
 import (
+	"encoding/json"
 	"net/http"
 )
 
+// Hook defines request/response hook that can rewrite payload,
+// returned data replaces payload for the next hook, nil keeps payload unchanged.
+type Hook func(r *http.Request, data []byte) ([]byte, error)
+
 // SetRequestHookFunction defines function that will be used as request hook.
+// It always runs first and is replaced on each call, use AddRequestHook to register additional hooks.
 func (s *Service) SetRequestHookFunction(f func(r *http.Request, data []byte) error) {
 	s.req = f
 }
 
-// SetResponseHookFunction defines function that will be used as request hook.
+// SetResponseHookFunction defines function that will be used as response hook.
+// It always runs first and is replaced on each call, use AddResponseHook to register additional hooks.
 func (s *Service) SetResponseHookFunction(f func(r *http.Request, data []byte) error) {
 	s.resp = f
 }
 
+// AddRequestHook appends hooks that run in order just after request body is read.
+func (s *Service) AddRequestHook(h ...Hook) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.reqHooks = append(s.reqHooks, h...)
+}
+
+// AddResponseHook appends hooks that run in order just before response is written.
+func (s *Service) AddResponseHook(h ...Hook) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.respHooks = append(s.respHooks, h...)
+}
+
+// SetHookErrorResponse enables JSON-RPC error response body for request/response hook failures,
+// HTTP status code from hook error is kept.
+func (s *Service) SetHookErrorResponse(flag bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.hookErrorResponse = flag
+}
+
+// GetHookErrorResponse returns true when hook failures emit JSON-RPC error response body.
+func (s *Service) GetHookErrorResponse() bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.hookErrorResponse
+}
+
+// SetHookErrorCode defines error code of JSON-RPC error response for hook failures.
+func (s *Service) SetHookErrorCode(code int) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.hookErrorCode = code
+}
+
+// GetHookErrorCode returns error code of JSON-RPC error response for hook failures.
+func (s *Service) GetHookErrorCode() int {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.hookErrorCode
+}
+
+// writeHookError writes hook failure to HTTP response writer, hook error HTTP code or 500 is used as status code.
+// Response hooks do not run for hook failure response.
+func (s *Service) writeHookError(w http.ResponseWriter, r *http.Request, id *json.RawMessage, err error) {
+	// hook failures without response body
+	if !s.GetHookErrorResponse() {
+		// set response header to custom HTTP code from hook error
+		// or fallback to 500, (internal server error)
+		w.WriteHeader(getHTTPCodeFromHookError(err))
+
+		return
+	}
+
+	// create default response object
+	respObj := DefaultResponseObject()
+
+	// set response ID
+	respObj.ID = id
+
+	// hook error message as error data
+	msg := err.Error()
+	if v, ok := err.(*HookError); ok {
+		msg = v.ErrorMsg
+	}
+
+	// define Error object
+	respObj.Error = &ErrorObject{
+		Code:    s.GetHookErrorCode(),
+		Message: HookErrorMessage,
+		Data:    msg,
+	}
+
+	// set response headers
+	s.writeHeaders(w, r)
+
+	// set response header to custom HTTP code from hook error
+	// or fallback to 500, (internal server error)
+	w.WriteHeader(getHTTPCodeFromHookError(err))
+
+	// write data to HTTP writer interface
+	_, _ = w.Write(respObj.Marshal())
+}
+
+// runRequestHooks runs request hook function and request hooks, returns possibly rewritten request body.
+func (s *Service) runRequestHooks(r *http.Request, data []byte) ([]byte, error) {
+	s.Lock()
+	hooks := make([]Hook, len(s.reqHooks))
+	copy(hooks, s.reqHooks)
+	s.Unlock()
+
+	return runHooks(s.req, hooks, r, data)
+}
+
+// runResponseHooks runs response hook function and response hooks, returns possibly rewritten response body.
+func (s *Service) runResponseHooks(r *http.Request, data []byte) ([]byte, error) {
+	s.Lock()
+	hooks := make([]Hook, len(s.respHooks))
+	copy(hooks, s.respHooks)
+	s.Unlock()
+
+	return runHooks(s.resp, hooks, r, data)
+}
+
+// runHooks runs hook function followed by hooks, stops on first error.
+func runHooks(f func(r *http.Request, data []byte) error, hooks []Hook, r *http.Request, data []byte) ([]byte, error) {
+	if f != nil {
+		if err := f(r, data); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, h := range hooks {
+		out, err := h(r, data)
+		if err != nil {
+			return nil, err
+		}
+
+		if out != nil {
+			data = out
+		}
+	}
+
+	return data, nil
+}
+
 // HookError custom error for Request/Response hook.
 type HookError struct {
 	ErrorMsg string
@@ -2,8 +2,12 @@ package jrpc2
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+
+	"github.com/gorilla/websocket"
 )
 
 /*
@@ -14,11 +18,39 @@ import (
 
 // WriteResponse writes JSON-RPC 2.0 response object to HTTP response writer.
 func (s *Service) WriteResponse(w http.ResponseWriter, respObj *ResponseObject) {
+	// notification does not send responses to client
+	if notificationFlagFromContext(respObj.r.Context()) {
+		s.writeResponseData(w, respObj.r, nil)
+
+		// end response processing
+		return
+	}
+
+	// write response bytes to HTTP writer
+	s.writeResponseData(w, respObj.r, respObj.Marshal())
+}
+
+// WriteBatchResponse writes JSON-RPC 2.0 batch response objects to HTTP response writer.
+func (s *Service) WriteBatchResponse(w http.ResponseWriter, r *http.Request, respObjs []*ResponseObject) {
+	// batch of notifications does not send responses to client
+	if len(respObjs) == 0 {
+		s.writeResponseData(w, setNotification(r), nil)
+
+		// end response processing
+		return
+	}
+
+	// write response bytes to HTTP writer
+	s.writeResponseData(w, r, marshalBatchResponse(respObjs))
+}
+
+// writeHeaders sets custom and dynamic response headers to HTTP response writer.
+func (s *Service) writeHeaders(w http.ResponseWriter, r *http.Request) {
 	// set custom response headers
 	var headers = s.GetHeaders()
 
 	// set dynamic response headers
-	for header, value := range headersFromContext(respObj.r.Context()) {
+	for header, value := range headersFromContext(r.Context()) {
 		headers[header] = value
 	}
 
@@ -26,12 +58,18 @@ func (s *Service) WriteResponse(w http.ResponseWriter, respObj *ResponseObject)
 	for header, value := range headers {
 		w.Header().Set(header, value)
 	}
+}
+
+// writeResponseData writes encoded response data to HTTP response writer, nil data writes only headers.
+func (s *Service) writeResponseData(w http.ResponseWriter, r *http.Request, resp []byte) {
+	// set response headers
+	s.writeHeaders(w, r)
 
 	// get HTTP Status code from Request Context
-	statusCode := httpStatusCodeFlagFromContext(respObj.r.Context())
+	statusCode := httpStatusCodeFlagFromContext(r.Context())
 
-	// notification does not send responses to client
-	if notificationFlagFromContext(respObj.r.Context()) {
+	// no response data to write
+	if resp == nil {
 		// write response code to HTTP writer interface
 		w.WriteHeader(statusCode)
 
@@ -39,20 +77,19 @@ func (s *Service) WriteResponse(w http.ResponseWriter, respObj *ResponseObject)
 		return
 	}
 
-	// get response bytes
-	resp := respObj.Marshal()
-
-	// run response hook function
-	err := s.resp(respObj.r, resp)
+	// run response hooks, they can rewrite response data
+	out, err := s.runResponseHooks(r, resp)
 	if err != nil { // hook failed
-		// set response header to custom HTTP code from hook error
-		// or fallback to 500, (internal server error)
-		w.WriteHeader(getHTTPCodeFromHookError(err))
+		// write hook failure with response ID when available
+		s.writeHookError(w, r, recoverRequestID(resp), err)
 
 		// end response processing
 		return
 	}
 
+	// use response data rewritten by hooks
+	resp = out
+
 	// write response code to HTTP writer interface
 	w.WriteHeader(statusCode)
 
@@ -67,6 +104,15 @@ func (s *Service) WriteResponse(w http.ResponseWriter, respObj *ResponseObject)
 	}
 }
 
+// limitedReader limits reader to one byte over provided limit, 0 disables limit.
+func limitedReader(r io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+
+	return io.LimitReader(r, limit+1)
+}
+
 // ServeHTTP implements needed interface for HTTP library, handles incoming RPC client requests, generates responses.
 func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// update HTTP request with new context
@@ -80,6 +126,18 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// WebSocket handshake
+	if websocket.IsWebSocketUpgrade(r) && s.GetWebSocket() {
+		// serve WebSocket connection until it is closed
+		s.serveWebSocket(w, r)
+
+		// end request processing
+		return
+	}
+
+	// method panics can abort HTTP handler on this goroutine
+	r = setAbortHandler(r, true)
+
 	// create empty error object
 	var errObj *ErrorObject
 
@@ -89,8 +147,11 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// set pointer to HTTP request object
 	respObj.r = r
 
-	// read request body as early as possible
-	req, err := ioutil.ReadAll(r.Body)
+	// get request body size limit
+	limit := s.GetMaxRequestSizeForRoute(r.URL.Path)
+
+	// read request body as early as possible, one byte over limit detects oversized body
+	req, err := ioutil.ReadAll(limitedReader(r.Body, limit))
 	if err != nil {
 		// set Response status code to 400 (bad request)
 		r = setHTTPStatusCode(r, http.StatusBadRequest)
@@ -112,19 +173,46 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// run request hook function
-	err = s.req(r, req)
+	// request body exceeds size limit
+	if limit > 0 && int64(len(req)) > limit {
+		// set Response status code to 413 (request entity too large)
+		r = setHTTPStatusCode(r, http.StatusRequestEntityTooLarge)
+
+		// set pointer to HTTP request object
+		respObj.r = r
+
+		// set response ID when it precedes size limit
+		respObj.ID = recoverRequestID(req[:limit])
+
+		// define Error object
+		respObj.Error = &ErrorObject{
+			Code:    InvalidRequestCode,
+			Message: InvalidRequestMessage,
+			Data:    fmt.Sprintf("request body must not exceed %d bytes", limit),
+		}
+
+		// write response to HTTP writer
+		s.WriteResponse(w, respObj)
+
+		// end request processing
+		return
+	}
+
+	// run request hooks, they can rewrite request body
+	out, err := s.runRequestHooks(r, req)
 	if err != nil { // hook failed
-		// set response header to custom HTTP code from hook error
-		// or fallback to 500, (internal server error)
-		w.WriteHeader(getHTTPCodeFromHookError(err))
+		// write hook failure with request ID when available
+		s.writeHookError(w, r, recoverRequestID(req), err)
 
-		// end response processing
+		// end request processing
 		return
 	}
 
+	// use request body rewritten by hooks
+	req = out
+
 	// check HTTP protocol version
-	if ok := respObj.ValidateHTTPProtocolVersion(r); !ok {
+	if ok := respObj.ValidateHTTPProtocolVersions(r, s.GetHTTPVersions()); !ok {
 		// write response to HTTP writer
 		s.WriteResponse(w, respObj)
 
@@ -150,6 +238,15 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// batch request
+	if isBatchRequest(req) {
+		// process batch and write response to HTTP writer
+		s.serveBatch(w, respObj, req)
+
+		// end request processing
+		return
+	}
+
 	// create placeholder for request object
 	reqObj := new(RequestObject)
 
@@ -166,22 +263,6 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		switch v := err.(type) {
 		// wrong data type data in request
 		case *json.UnmarshalTypeError:
-			// array data, batch request
-			if v.Value == "array" {
-				// define Error object
-				respObj.Error = &ErrorObject{
-					Code:    NotImplementedCode,
-					Message: NotImplementedMessage,
-					Data:    "batch requests not supported",
-				}
-
-				// write response to HTTP writer
-				s.WriteResponse(w, respObj)
-
-				// end request processing
-				return
-			}
-
 			// invalid data type for method
 			if v.Field == "method" { // name of the field holding the Go value
 				// define Error object
@@ -258,6 +339,9 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// invoke named method with the provided parameters
 	respObj.Result, errObj = s.Call(reqObj.Method, paramsObj)
+
+	// signal deprecated alias call in response headers
+	respObj.r = s.setDeprecationHeader(r, reqObj.Method)
 	if errObj != nil {
 		// define Error object
 		respObj.Error = errObj
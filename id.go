@@ -1,6 +1,7 @@
 package jrpc2
 
 import (
+	"bytes"
 	"encoding/json"
 	"math"
 	"strconv"
@@ -49,3 +50,40 @@ func ConvertIDtoString(id *json.RawMessage) (string, *ErrorObject) {
 		}
 	}
 }
+
+// recoverRequestID attempts to find valid ID member in top level object of possibly truncated request or response body.
+func recoverRequestID(data []byte) *json.RawMessage {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	// request must be an object
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+
+		var value json.RawMessage
+
+		// value is truncated or malformed
+		if err = dec.Decode(&value); err != nil {
+			return nil
+		}
+
+		if key != "id" {
+			continue
+		}
+
+		// validate ID data type
+		if _, errObj := ConvertIDtoString(&value); errObj != nil {
+			return nil
+		}
+
+		return &value
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package jrpc2
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// errorResponse create a bytes encoded representation of response object with provided error.
+func errorResponse(errObj *ErrorObject) []byte {
+	// create default response object
+	respObj := DefaultResponseObject()
+
+	// define Error object
+	respObj.Error = errObj
+
+	return respObj.Marshal()
+}
+
+// handleMessage processes single JSON-RPC 2.0 message (request, notification or batch) received over
+// message-oriented transport, returns encoded response, nil when there is nothing to send back.
+func (s *Service) handleMessage(r *http.Request, data []byte) []byte {
+	// message must be valid JSON
+	if !json.Valid(data) {
+		return errorResponse(&ErrorObject{
+			Code:    ParseErrorCode,
+			Message: ParseErrorMessage,
+			Data:    "message must be valid JSON",
+		})
+	}
+
+	// single request
+	if !isBatchRequest(data) {
+		respObj := s.callBatchElement(r, decodeBatchElement(data))
+		if respObj == nil {
+			return nil
+		}
+
+		return respObj.Marshal()
+	}
+
+	// decode and validate batch request
+	batch, errObj := s.decodeBatch(data)
+	if errObj != nil {
+		return errorResponse(errObj)
+	}
+
+	respObjs := s.callBatch(r, batch)
+
+	// batch of notifications does not send responses to client
+	if len(respObjs) == 0 {
+		return nil
+	}
+
+	return marshalBatchResponse(respObjs)
+}
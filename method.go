@@ -1,7 +1,36 @@
 package jrpc2
 
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
 // method represents an JSON-RPC 2.0 method.
 type method struct {
 	// Method is the callable function
 	Method func(ParametersObject) (interface{}, *ErrorObject)
+	// ContextMethod is the callable function that accepts method context
+	ContextMethod func(context.Context, ParametersObject) (interface{}, *ErrorObject)
+
+	// timeout overrides service method timeout when not 0
+	timeout time.Duration
+
+	// validator checks raw JSON params before method invocation
+	validator *paramsValidator
+
+	// fields below are used for service discovery
+	argType    reflect.Type      // type of typed method Args
+	replyType  reflect.Type      // type of typed method Reply
+	descriptor *MethodDescriptor // explicit method description
+}
+
+// invoke calls method function with provided parameters, context-aware method receives method context.
+func (m method) invoke(data ParametersObject) (interface{}, *ErrorObject) {
+	// context-aware method
+	if m.ContextMethod != nil {
+		return m.ContextMethod(data.GetContext(), data)
+	}
+
+	return m.Method(data)
 }
@@ -0,0 +1,90 @@
+package jrpc2
+
+import (
+	"strings"
+)
+
+// Handler represents method invocation, returns method result or error object.
+// Method name and method context are available from parameters object.
+type Handler func(data ParametersObject) (interface{}, *ErrorObject)
+
+// Middleware wraps method invocation, it can inspect parameters object, result and error object
+// of the next handler or return early without calling it.
+type Middleware func(next Handler) Handler
+
+// namespaceMiddleware holds middleware attached to method name prefix.
+type namespaceMiddleware struct {
+	prefix     string
+	middleware Middleware
+}
+
+// Use appends middleware to the chain around method invocation, the first added middleware is the outermost.
+func (s *Service) Use(mw ...Middleware) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.middleware = append(s.middleware, mw...)
+}
+
+// UseMethod appends middleware to the chain of named method only.
+// Method middleware runs after global and namespace middleware. Middleware of registered method also runs
+// for calls through its aliases (and unversioned name), middleware of alias runs only for calls through alias.
+func (s *Service) UseMethod(name string, mw ...Middleware) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.methodMiddleware == nil {
+		s.methodMiddleware = make(map[string][]Middleware)
+	}
+
+	s.methodMiddleware[name] = append(s.methodMiddleware[name], mw...)
+}
+
+// UseNamespace appends middleware to the chain of methods which names start with provided prefix, (e.g. "admin.").
+// Namespace middleware runs after global middleware, in the order it was added.
+// Prefix is matched against registered method name that alias resolves to.
+func (s *Service) UseNamespace(prefix string, mw ...Middleware) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, el := range mw {
+		s.namespaceMiddleware = append(s.namespaceMiddleware, namespaceMiddleware{
+			prefix:     prefix,
+			middleware: el,
+		})
+	}
+}
+
+// chain wraps handler with middleware chain that applies to called method name and its resolved target method.
+func (s *Service) chain(name, target string, h Handler) Handler {
+	s.Lock()
+
+	mw := make([]Middleware, 0, len(s.middleware))
+
+	// global middleware
+	mw = append(mw, s.middleware...)
+
+	// namespace middleware
+	for _, el := range s.namespaceMiddleware {
+		if strings.HasPrefix(target, el.prefix) {
+			mw = append(mw, el.middleware)
+		}
+	}
+
+	// method middleware
+	mw = append(mw, s.methodMiddleware[target]...)
+
+	// called alias middleware
+	if name != target {
+		mw = append(mw, s.methodMiddleware[name]...)
+	}
+
+	s.Unlock()
+
+	// the last added middleware wraps handler first
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+
+	return h
+}
@@ -0,0 +1,268 @@
+package jrpc2
+
+import (
+	"reflect"
+	"sort"
+)
+
+/*
+  Specification URLs:
+    - https://spec.open-rpc.org
+*/
+
+// OpenRPCDocument represents OpenRPC service description document.
+type OpenRPCDocument struct {
+	// OpenRPC specifies the version of the OpenRPC specification
+	OpenRPC string `json:"openrpc"`
+	// Info provides metadata about the service
+	Info OpenRPCInfo `json:"info"`
+	// Methods contains descriptions of registered methods
+	Methods []MethodDescriptor `json:"methods"`
+}
+
+// OpenRPCInfo represents OpenRPC info object.
+type OpenRPCInfo struct {
+	// Title contains the title of the service
+	Title string `json:"title"`
+	// Description contains verbose description of the service
+	Description string `json:"description,omitempty"`
+	// Version contains the version of the service
+	Version string `json:"version"`
+}
+
+// MethodDescriptor represents OpenRPC method object.
+type MethodDescriptor struct {
+	// Name contains the name of the method
+	Name string `json:"name"`
+	// Summary contains short summary of what the method does
+	Summary string `json:"summary,omitempty"`
+	// Description contains verbose explanation of the method behavior
+	Description string `json:"description,omitempty"`
+	// Params contains descriptions of method params
+	Params []ContentDescriptor `json:"params"`
+	// Result contains description of method result
+	Result *ContentDescriptor `json:"result,omitempty"`
+	// Deprecated declares this method to be deprecated
+	Deprecated bool `json:"deprecated,omitempty"`
+	// ParamStructure defines expected params structure, one of "by-name", "by-position" or "either"
+	ParamStructure string `json:"paramStructure,omitempty"`
+}
+
+// ContentDescriptor represents OpenRPC content descriptor object.
+type ContentDescriptor struct {
+	// Name contains the name of the content
+	Name string `json:"name"`
+	// Description contains verbose explanation of the content
+	Description string `json:"description,omitempty"`
+	// Required determines if the content is required
+	Required bool `json:"required,omitempty"`
+	// Schema contains JSON Schema that describes the content
+	Schema interface{} `json:"schema"`
+}
+
+// defaultOpenRPCInfo returns default service metadata for service discovery document.
+func defaultOpenRPCInfo() OpenRPCInfo {
+	return OpenRPCInfo{
+		Title:   "JSON-RPC 2.0 Service",
+		Version: "0.0.0",
+	}
+}
+
+// describe generates OpenRPC method description from typed method signature and explicit descriptor.
+func (m method) describe(name string) MethodDescriptor {
+	d := MethodDescriptor{
+		Name:   name,
+		Params: make([]ContentDescriptor, 0),
+	}
+
+	// params schema from typed method Args
+	if m.argType != nil {
+		d.Params, d.ParamStructure = describeParams(m.argType)
+	}
+
+	// params schema from explicit JSON Schema
+	if m.validator != nil {
+		d.Params, d.ParamStructure = describeSchemaParams(m.validator.root)
+	}
+
+	// result schema from typed method Reply
+	if m.replyType != nil {
+		d.Result = &ContentDescriptor{
+			Name:   "result",
+			Schema: jsonSchema(m.replyType),
+		}
+	}
+
+	// explicit descriptor overrides generated description
+	if m.descriptor != nil {
+		if m.descriptor.Summary != "" {
+			d.Summary = m.descriptor.Summary
+		}
+
+		if m.descriptor.Description != "" {
+			d.Description = m.descriptor.Description
+		}
+
+		if m.descriptor.Params != nil {
+			d.Params = m.descriptor.Params
+		}
+
+		if m.descriptor.Result != nil {
+			d.Result = m.descriptor.Result
+		}
+
+		if m.descriptor.ParamStructure != "" {
+			d.ParamStructure = m.descriptor.ParamStructure
+		}
+
+		d.Deprecated = m.descriptor.Deprecated
+	}
+
+	// result is required by specification
+	if d.Result == nil {
+		d.Result = &ContentDescriptor{
+			Name:   "result",
+			Schema: map[string]interface{}{},
+		}
+	}
+
+	return d
+}
+
+// describeParams generates OpenRPC params description from typed method Args.
+func describeParams(t reflect.Type) ([]ContentDescriptor, string) {
+	base := t
+	if base.Kind() == reflect.Ptr {
+		base = base.Elem()
+	}
+
+	// non-struct Args are described as a single param
+	if base.Kind() != reflect.Struct {
+		return []ContentDescriptor{
+			{
+				Name:     "params",
+				Required: t.Kind() != reflect.Ptr,
+				Schema:   jsonSchema(base),
+			},
+		}, "by-position"
+	}
+
+	schema := jsonSchema(base)
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	required, _ := schema["required"].([]string)
+
+	params := make([]ContentDescriptor, 0, len(properties))
+
+	// keep fields declaration order, same as positional params
+	for _, i := range positionalFields(base) {
+		name, _ := jsonFieldName(base.Field(i))
+		if name == "" {
+			continue
+		}
+
+		param := ContentDescriptor{
+			Name:   name,
+			Schema: properties[name],
+		}
+
+		for _, el := range required {
+			if el == name {
+				param.Required = true
+			}
+		}
+
+		params = append(params, param)
+	}
+
+	return params, "either"
+}
+
+// describeSchemaParams generates OpenRPC params description from params JSON Schema.
+func describeSchemaParams(schema interface{}) ([]ContentDescriptor, string) {
+	node, _ := schema.(map[string]interface{})
+	properties, _ := node["properties"].(map[string]interface{})
+
+	// schema without properties is described as a single param
+	if len(properties) == 0 {
+		return []ContentDescriptor{
+			{
+				Name:   "params",
+				Schema: schema,
+			},
+		}, ""
+	}
+
+	required := make(map[string]bool)
+
+	if list, ok := node["required"].([]interface{}); ok {
+		for _, el := range list {
+			if name, ok := el.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	params := make([]ContentDescriptor, 0, len(names))
+
+	for _, name := range names {
+		params = append(params, ContentDescriptor{
+			Name:     name,
+			Required: required[name],
+			Schema:   properties[name],
+		})
+	}
+
+	return params, "by-name"
+}
+
+// SetOpenRPCInfo sets service title and version used in service discovery document.
+func (s *Service) SetOpenRPCInfo(title, version string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.info = OpenRPCInfo{
+		Title:   title,
+		Version: version,
+	}
+}
+
+// SetMethodDescriptor sets explicit OpenRPC description for registered method.
+func (s *Service) SetMethodDescriptor(name string, d MethodDescriptor) error {
+	return s.updateMethod(name, func(m *method) {
+		m.descriptor = &d
+	})
+}
+
+// GetOpenRPCDocument generates OpenRPC service description document from registered methods.
+func (s *Service) GetOpenRPCDocument() OpenRPCDocument {
+	s.Lock()
+	defer s.Unlock()
+
+	doc := OpenRPCDocument{
+		OpenRPC: OpenRPCVersion,
+		Info:    s.info,
+		Methods: make([]MethodDescriptor, 0, len(s.methods)),
+	}
+
+	for name, m := range s.methods {
+		doc.Methods = append(doc.Methods, m.describe(name))
+	}
+
+	// aliases are described as their target methods
+	doc.Methods = append(doc.Methods, s.describeAliases()...)
+
+	// stable methods order
+	sort.Slice(doc.Methods, func(i, j int) bool {
+		return doc.Methods[i].Name < doc.Methods[j].Name
+	})
+
+	return doc
+}
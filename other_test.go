@@ -3,15 +3,25 @@ package jrpc2
 import (
 	"bufio"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"os/exec"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/s3rj1k/jrpc2/client"
 )
 
 func TestParametersObjectMethods(t *testing.T) {
@@ -651,13 +661,14 @@ func TestStart(t *testing.T) {
 	err := testService.Start()
 	_verifyequal(t, err == nil, false) // expecting error
 
-	testService.socket = &sock
-	testService.address = &sock
+	testService.SetAddress(sock)
+	_verifyequal(t, testService.GetSocket(), "") // socket is unset
 
 	err = testService.Start()
 	_verifyequal(t, err == nil, false) // expecting error
 
-	testService.address = nil
+	testService.SetSocket(sock)
+	_verifyequal(t, testService.GetAddress(), "") // address is unset
 
 	_createfile(t, sock, []byte(""))
 	defer os.Remove(sock)
@@ -680,12 +691,6 @@ func TestTCPStart(t *testing.T) {
 	_verifyequal(t, err == nil, false) // expecting error
 
 	testService.address = &addr // fix
-	testService.socket = &addr  // creating error conditions
-
-	err = testService.StartTCPTLS()
-	_verifyequal(t, err == nil, false) // expecting error
-
-	testService.socket = nil // fix
 
 	err = testService.StartTCPTLS()
 	_verifyequal(t, err == nil, false) // expecting error
@@ -699,6 +704,58 @@ func TestTCPStart(t *testing.T) {
 	_verifyequal(t, err == nil, false) // expecting error
 }
 
+func TestServeListener(t *testing.T) {
+	var result Result
+
+	testService := CreateOverTCP("127.0.0.1:0", "/rpc")
+	testService.Register("update", Update)
+
+	_verifyequal(t, testService.GetSocket(), "")
+	_verifyequal(t, testService.GetCertificateFilePath(), "")
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- testService.ServeListener(l)
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, "http://"+l.Addr().String()+"/rpc", strings.NewReader(`{"jsonrpc": "2.0", "method": "update", "id": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, result.ID, float64(1))
+	_verifyequal(t, result.Error == nil, true)
+
+	if err = testService.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, <-errCh, nil)
+
+	// plain TCP mode requires network address
+	_verifyequal(t, Create("").StartTCP() == nil, false)
+}
+
 func TestContextWithBehindReverseProxyFlag(t *testing.T) {
 	ctx := context.Background()
 
@@ -901,3 +958,2627 @@ func _createfile(t *testing.T, path string, contents []byte) {
 
 	wr.Flush()
 }
+
+func TestConcurrentBatch(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		order   []float64
+		results []Result
+	)
+
+	testService := Create("")
+	testService.SetBatchConcurrency(4)
+
+	testService.Register("sleep", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		time.Sleep(100 * time.Millisecond)
+
+		return true, nil
+	})
+
+	testService.Register("append", func(data ParametersObject) (interface{}, *ErrorObject) {
+		params, errObj := GetPositionalFloat64Params(data)
+		if errObj != nil {
+			return nil, errObj
+		}
+
+		mu.Lock()
+		order = append(order, params...)
+		mu.Unlock()
+
+		return nil, nil
+	})
+
+	testService.SetSequentialMethods([]string{"append"})
+	_verifyequal(t, testService.GetSequentialMethods(), []string{"append"})
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		rec := httptest.NewRecorder()
+		testService.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	start := time.Now()
+	rec := serve(`[
+		{"jsonrpc": "2.0", "method": "sleep", "id": 1},
+		{"jsonrpc": "2.0", "method": "sleep", "id": 2},
+		{"jsonrpc": "2.0", "method": "sleep", "id": 3},
+		{"jsonrpc": "2.0", "method": "sleep", "id": 4}
+	]`)
+
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Fatalf("expected batch elements to run concurrently, took '%s'", elapsed)
+	}
+
+	if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, len(results), 4)
+
+	for i, el := range results {
+		_verifyequal(t, el.ID, float64(i+1))
+		_verifyequal(t, el.Result, true)
+	}
+
+	rec = serve(`[
+		{"jsonrpc": "2.0", "method": "append", "params": [1]},
+		{"jsonrpc": "2.0", "method": "sleep"},
+		{"jsonrpc": "2.0", "method": "append", "params": [2]},
+		{"jsonrpc": "2.0", "method": "append", "params": [3]},
+		{"jsonrpc": "2.0", "method": "sleep"},
+		{"jsonrpc": "2.0", "method": "append", "params": [4]}
+	]`)
+	_verifyequal(t, rec.Code, http.StatusNoContent)
+	_verifyequal(t, order, []float64{1, 2, 3, 4})
+
+	testService.SetBatchSizeLimit(2)
+	_verifyequal(t, testService.GetBatchSizeLimit(), 2)
+
+	var result Result
+
+	rec = serve(`[
+		{"jsonrpc": "2.0", "method": "sleep", "id": 1},
+		{"jsonrpc": "2.0", "method": "sleep", "id": 2},
+		{"jsonrpc": "2.0", "method": "sleep", "id": 3}
+	]`)
+
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyerrobj(t, result.Error, InvalidRequestCode, InvalidRequestMessage)
+}
+
+func TestRegisterContext(t *testing.T) {
+	type ctxKeyTest struct{}
+
+	testService := Create("")
+	testService.SetMethodTimeout(50 * time.Millisecond)
+	_verifyequal(t, testService.GetMethodTimeout(), 50*time.Millisecond)
+
+	testService.RegisterContext("value", func(ctx context.Context, data ParametersObject) (interface{}, *ErrorObject) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expected method context to have deadline")
+		}
+
+		if data.GetContext() != ctx {
+			t.Error("expected parameters object to carry method context")
+		}
+
+		return ctx.Value(ctxKeyTest{}), nil
+	})
+
+	testService.RegisterContext("wait", func(ctx context.Context, _ ParametersObject) (interface{}, *ErrorObject) {
+		<-ctx.Done()
+
+		return nil, &ErrorObject{
+			Code:    InternalErrorCode,
+			Message: InternalErrorMessage,
+			Data:    ctx.Err().Error(),
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxKeyTest{}, "hooked"))
+
+	result, errObj := testService.Call("value", ParametersObject{r: req})
+	if errObj != nil {
+		t.Fatalf("unexpected error '%v'", errObj)
+	}
+
+	_verifyequal(t, result, "hooked")
+
+	_, errObj = testService.Call("wait", ParametersObject{r: req})
+	_verifyerrobj(t, errObj, MethodTimeoutCode, MethodTimeoutMessage)
+
+	// client goes away
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testService.SetMethodTimeout(0)
+
+	_, errObj = testService.Call("wait", ParametersObject{r: req.WithContext(ctx)})
+	_verifyequal(t, errObj.Data, context.Canceled.Error())
+}
+
+type TypedArgs struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type TypedReply struct {
+	Z float64 `json:"z"`
+}
+
+func TestMethodTimeout(t *testing.T) {
+	var result Result
+
+	testService := Create("")
+
+	testService.Register("sleep", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		time.Sleep(time.Second)
+
+		return true, nil
+	})
+
+	testService.Register("fast", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return true, nil
+	})
+
+	_verifyequal(t, testService.SetMethodTimeoutFor("nonexistent", time.Second) == nil, false)
+
+	if err := testService.SetMethodTimeoutFor("sleep", 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc": "2.0", "method": "sleep", "id": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	testService.ServeHTTP(rec, req)
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected method timeout to fire, took '%s'", elapsed)
+	}
+
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, result.ID, float64(1))
+	_verifyerrobj(t, result.Error, MethodTimeoutCode, MethodTimeoutMessage)
+
+	// service-wide timeout does not affect fast methods
+	testService.SetMethodTimeout(time.Second)
+
+	value, errObj := testService.Call("fast", ParametersObject{r: req})
+	if errObj != nil {
+		t.Fatalf("unexpected error '%v'", errObj)
+	}
+
+	_verifyequal(t, value, true)
+
+	// timeout fires for direct calls without HTTP request
+	start = time.Now()
+
+	_, errObj = testService.Call("sleep", ParametersObject{})
+	_verifyerrobj(t, errObj, MethodTimeoutCode, MethodTimeoutMessage)
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected method timeout to fire, took '%s'", elapsed)
+	}
+
+	// context-aware method receives cancellable method context
+	cancelled := make(chan error, 1)
+
+	testService.RegisterContext("wait", func(ctx context.Context, _ ParametersObject) (interface{}, *ErrorObject) {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+
+		return nil, nil
+	})
+
+	if err := testService.SetMethodTimeoutFor("wait", 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	_, errObj = testService.Call("wait", ParametersObject{})
+	_verifyerrobj(t, errObj, MethodTimeoutCode, MethodTimeoutMessage)
+
+	select {
+	case err := <-cancelled:
+		_verifyequal(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("expected method context to be cancelled")
+	}
+}
+
+func TestMaxRequestSize(t *testing.T) {
+	testService := Create("")
+	testService.Register("copy", CopyParamsData)
+
+	testService.SetMaxRequestSize(64)
+	testService.SetRouteMaxRequestSize("/big", 1024)
+
+	_verifyequal(t, testService.GetMaxRequestSize(), int64(64))
+	_verifyequal(t, testService.GetMaxRequestSizeForRoute("/"), int64(64))
+	_verifyequal(t, testService.GetMaxRequestSizeForRoute("/big"), int64(1024))
+
+	params := strings.Repeat("x", 100)
+
+	serve := func(route, body string) (*httptest.ResponseRecorder, Result) {
+		var result Result
+
+		req := httptest.NewRequest(http.MethodPost, route, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		rec := httptest.NewRecorder()
+		testService.ServeHTTP(rec, req)
+
+		if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+
+		return rec, result
+	}
+
+	// ID precedes size limit
+	rec, result := serve("/", `{"jsonrpc": "2.0", "id": "ID:42", "method": "copy", "params": ["`+params+`"]}`)
+	_verifyequal(t, rec.Code, http.StatusRequestEntityTooLarge)
+	_verifyequal(t, result.ID, "ID:42")
+	_verifyerrobj(t, result.Error, InvalidRequestCode, InvalidRequestMessage)
+
+	// ID is truncated
+	rec, result = serve("/", `{"jsonrpc": "2.0", "method": "copy", "params": ["`+params+`"], "id": "ID:42"}`)
+	_verifyequal(t, rec.Code, http.StatusRequestEntityTooLarge)
+	_verifyequal(t, result.ID, nil)
+	_verifyerrobj(t, result.Error, InvalidRequestCode, InvalidRequestMessage)
+
+	// route limit override
+	rec, result = serve("/big", `{"jsonrpc": "2.0", "id": "ID:42", "method": "copy", "params": ["`+params+`"]}`)
+	_verifyequal(t, rec.Code, http.StatusOK)
+	_verifyequal(t, result.ID, "ID:42")
+	_verifyequal(t, result.Error == nil, true)
+
+	// request exactly at size limit
+	body := `{"jsonrpc": "2.0", "method": "copy", "id": 1}`
+	testService.SetMaxRequestSize(int64(len(body)))
+
+	rec, result = serve("/", body)
+	_verifyequal(t, rec.Code, http.StatusOK)
+	_verifyequal(t, result.Error == nil, true)
+}
+
+func TestRecoverRequestID(t *testing.T) {
+	_verifyequal(t, recoverRequestID([]byte(`[{"id": 1}]`)), (*json.RawMessage)(nil))
+	_verifyequal(t, recoverRequestID([]byte(`{"id": {}}`)), (*json.RawMessage)(nil))
+	_verifyequal(t, recoverRequestID([]byte(`{"params": [1, 2`)), (*json.RawMessage)(nil))
+	_verifyequal(t, string(*recoverRequestID([]byte(`{"params": [1, 2], "id": 42, "method": "trun`))), "42")
+	_verifyequal(t, string(*recoverRequestID([]byte(`{"id": "ID:42", "params": [1, 2`))), `"ID:42"`)
+}
+
+func TestPanicRecovery(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		reported []string
+	)
+
+	testService := Create("")
+
+	testService.Register("panic", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		panic("boom")
+	})
+
+	testService.SetPanicHandler(func(name string, params json.RawMessage, stack []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		reported = append(reported, name, string(params))
+
+		if !strings.Contains(string(stack), "panic") {
+			t.Error("expected stack trace to be reported")
+		}
+	})
+
+	serve := func(body string) Result {
+		var result Result
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		rec := httptest.NewRecorder()
+		testService.ServeHTTP(rec, req)
+
+		if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+
+		return result
+	}
+
+	result := serve(`{"jsonrpc": "2.0", "method": "panic", "params": [1], "id": 1}`)
+	_verifyequal(t, result.ID, float64(1))
+	_verifyerrobj(t, result.Error, InternalErrorCode, InternalErrorMessage)
+	_verifyequal(t, result.Error.Data, "method panicked")
+	_verifyequal(t, reported, []string{"panic", "[1]"})
+
+	// panic inside method with execution timeout
+	testService.SetDebugMode(true)
+	testService.SetMethodTimeout(time.Second)
+	_verifyequal(t, testService.GetDebugMode(), true)
+
+	result = serve(`{"jsonrpc": "2.0", "method": "panic", "id": 2}`)
+	_verifyequal(t, result.ID, float64(2))
+	_verifyerrobj(t, result.Error, InternalErrorCode, InternalErrorMessage)
+
+	data, ok := result.Error.Data.(map[string]interface{})
+	if !ok {
+		t.Fatal("expected error data to be an object")
+	}
+
+	_verifyequal(t, data["panic"], "boom")
+	_verifyequal(t, strings.Contains(data["stack"].(string), "goroutine"), true)
+
+	// aborted HTTP handler on goroutine not owned by HTTP server is internal error
+	testService.Register("abort", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		panic(http.ErrAbortHandler)
+	})
+
+	result = serve(`{"jsonrpc": "2.0", "method": "abort", "id": 3}`)
+	_verifyequal(t, result.ID, float64(3))
+	_verifyerrobj(t, result.Error, InternalErrorCode, InternalErrorMessage)
+
+	testService.SetMethodTimeout(0)
+	testService.SetBatchConcurrency(2)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"jsonrpc": "2.0", "method": "abort", "id": 4}]`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	rec := httptest.NewRecorder()
+	testService.ServeHTTP(rec, req)
+
+	var results []Result
+	if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, len(results), 1)
+	_verifyerrobj(t, results[0].Error, InternalErrorCode, InternalErrorMessage)
+
+	// aborted HTTP handler on HTTP server goroutine reaches HTTP library
+	testService.SetBatchConcurrency(0)
+
+	func() {
+		defer func() {
+			_verifyequal(t, recover(), http.ErrAbortHandler)
+		}()
+
+		serve(`{"jsonrpc": "2.0", "method": "abort", "id": 5}`)
+	}()
+}
+
+func TestServerTimeouts(t *testing.T) {
+	testService := Create("")
+
+	testService.SetReadHeaderTimeout(1 * time.Second)
+	testService.SetReadTimeout(2 * time.Second)
+	testService.SetWriteTimeout(3 * time.Second)
+	testService.SetIdleTimeout(4 * time.Second)
+	testService.SetMaxHeaderBytes(4096)
+
+	_verifyequal(t, testService.GetReadHeaderTimeout(), 1*time.Second)
+	_verifyequal(t, testService.GetReadTimeout(), 2*time.Second)
+	_verifyequal(t, testService.GetWriteTimeout(), 3*time.Second)
+	_verifyequal(t, testService.GetIdleTimeout(), 4*time.Second)
+	_verifyequal(t, testService.GetMaxHeaderBytes(), 4096)
+
+	srv, err := testService.newHTTPServer(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, srv.ReadHeaderTimeout, 1*time.Second)
+	_verifyequal(t, srv.ReadTimeout, 2*time.Second)
+	_verifyequal(t, srv.WriteTimeout, 3*time.Second)
+	_verifyequal(t, srv.IdleTimeout, 4*time.Second)
+	_verifyequal(t, srv.MaxHeaderBytes, 4096)
+}
+
+func TestRegisterTyped(t *testing.T) {
+	testService := Create("")
+
+	err := testService.RegisterTyped("div", func(ctx context.Context, args *TypedArgs) (*TypedReply, error) {
+		if ctx == nil {
+			t.Error("expected context to be not 'nil'")
+		}
+
+		if args.Y == 0 {
+			return nil, &ErrorObject{
+				Code:    -32010,
+				Message: "Division by zero",
+			}
+		}
+
+		return &TypedReply{Z: args.X / args.Y}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = testService.RegisterTyped("fail", func(_ string) (bool, error) {
+		return false, fmt.Errorf("failed")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = testService.RegisterTyped("sum", func(args []int) (int, error) {
+		var out int
+
+		for _, el := range args {
+			out += el
+		}
+
+		return out, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(name, params string) (interface{}, *ErrorObject) {
+		return testService.Call(name, ParametersObject{
+			method: name,
+			params: json.RawMessage(params),
+			r:      httptest.NewRequest(http.MethodPost, "/", nil),
+		})
+	}
+
+	result, errObj := call("div", `{"x": 84, "y": 2}`)
+	if errObj != nil {
+		t.Fatalf("unexpected error '%v'", errObj)
+	}
+
+	_verifyequal(t, result, &TypedReply{Z: 42})
+
+	result, errObj = call("div", `[84, 4]`)
+	if errObj != nil {
+		t.Fatalf("unexpected error '%v'", errObj)
+	}
+
+	_verifyequal(t, result, &TypedReply{Z: 21})
+
+	_, errObj = call("div", `[84, 4, 2]`)
+	_verifyerrobj(t, errObj, InvalidParamsCode, InvalidParamsMessage)
+
+	_, errObj = call("div", `{"x": "84"}`)
+	_verifyerrobj(t, errObj, InvalidParamsCode, InvalidParamsMessage)
+
+	_, errObj = call("div", ``)
+	_verifyerrobj(t, errObj, -32010, "Division by zero")
+
+	_, errObj = call("fail", `["value"]`)
+	_verifyerrobj(t, errObj, InternalErrorCode, InternalErrorMessage)
+	_verifyequal(t, errObj.Data, "failed")
+
+	result, errObj = call("sum", `[1, 2, 3]`)
+	if errObj != nil {
+		t.Fatalf("unexpected error '%v'", errObj)
+	}
+
+	_verifyequal(t, result, 6)
+
+	// invalid signatures
+	_verifyequal(t, testService.RegisterTyped("bad", nil) == nil, false)
+	_verifyequal(t, testService.RegisterTyped("bad", "string") == nil, false)
+	_verifyequal(t, testService.RegisterTyped("bad", func() error { return nil }) == nil, false)
+	_verifyequal(t, testService.RegisterTyped("bad", func(_ int) int { return 0 }) == nil, false)
+	_verifyequal(t, testService.RegisterTyped("bad", func(_, _ int) (int, error) { return 0, nil }) == nil, false)
+}
+
+type Arith struct{}
+
+func (Arith) Echo(data ParametersObject) (interface{}, *ErrorObject) {
+	return data.GetMethodName(), nil
+}
+
+func (Arith) Deadline(ctx context.Context, _ ParametersObject) (interface{}, *ErrorObject) {
+	_, ok := ctx.Deadline()
+
+	return ok, nil
+}
+
+func (Arith) Add(args TypedArgs) (float64, error) {
+	return args.X + args.Y, nil
+}
+
+func (Arith) Unsuitable(_, _ int) int {
+	return 0
+}
+
+type arithState struct {
+	Value int
+}
+
+func (Arith) State(_ TypedArgs) (*arithState, error) {
+	return &arithState{}, nil
+}
+
+func TestRegisterService(t *testing.T) {
+	testService := Create("")
+
+	if err := testService.RegisterService(new(Arith), "", DotNaming); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := testService.RegisterService(Arith{}, "math", UnderscoreNaming); err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0)
+	for name := range testService.methods {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	_verifyequal(t, names, []string{
+		"Arith.Add", "Arith.Deadline", "Arith.Echo",
+		"math_add", "math_deadline", "math_echo",
+	})
+
+	result, errObj := testService.Call("math_add", ParametersObject{
+		params: json.RawMessage(`[40, 2]`),
+		r:      httptest.NewRequest(http.MethodPost, "/", nil),
+	})
+	if errObj != nil {
+		t.Fatalf("unexpected error '%v'", errObj)
+	}
+
+	_verifyequal(t, result, float64(42))
+
+	result, errObj = testService.Call("Arith.Echo", ParametersObject{
+		method: "Arith.Echo",
+		r:      httptest.NewRequest(http.MethodPost, "/", nil),
+	})
+	if errObj != nil {
+		t.Fatalf("unexpected error '%v'", errObj)
+	}
+
+	_verifyequal(t, result, "Arith.Echo")
+
+	_verifyequal(t, testService.RegisterService(nil, "", DotNaming) == nil, false)
+	_verifyequal(t, testService.RegisterService(struct{}{}, "", DotNaming) == nil, false)
+	_verifyequal(t, testService.RegisterService(struct{}{}, "empty", DotNaming) == nil, false)
+}
+
+func TestDiscover(t *testing.T) {
+	testService := Create("")
+	testService.SetOpenRPCInfo("Arith", "1.0.0")
+
+	testService.Register("update", Update)
+
+	if err := testService.RegisterTyped("div", func(args *TypedArgs) (*TypedReply, error) {
+		return &TypedReply{Z: args.X / args.Y}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := testService.SetMethodDescriptor("update", MethodDescriptor{
+		Summary:    "Does nothing",
+		Deprecated: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, testService.SetMethodDescriptor("nonexistent", MethodDescriptor{}) == nil, false)
+
+	result, errObj := testService.Call(DiscoverMethodName, ParametersObject{})
+	if errObj != nil {
+		t.Fatalf("unexpected error '%v'", errObj)
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		OpenRPC string `json:"openrpc"`
+		Info    struct {
+			Title   string `json:"title"`
+			Version string `json:"version"`
+		} `json:"info"`
+		Methods []struct {
+			Name       string `json:"name"`
+			Summary    string `json:"summary"`
+			Deprecated bool   `json:"deprecated"`
+			Params     []struct {
+				Name     string                 `json:"name"`
+				Required bool                   `json:"required"`
+				Schema   map[string]interface{} `json:"schema"`
+			} `json:"params"`
+			Result struct {
+				Name   string                 `json:"name"`
+				Schema map[string]interface{} `json:"schema"`
+			} `json:"result"`
+		} `json:"methods"`
+	}
+
+	if err = json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, doc.OpenRPC, OpenRPCVersion)
+	_verifyequal(t, doc.Info.Title, "Arith")
+	_verifyequal(t, doc.Info.Version, "1.0.0")
+	_verifyequal(t, len(doc.Methods), 2)
+
+	div := doc.Methods[0]
+	_verifyequal(t, div.Name, "div")
+	_verifyequal(t, len(div.Params), 2)
+	_verifyequal(t, div.Params[0].Name, "x")
+	_verifyequal(t, div.Params[0].Required, true)
+	_verifyequal(t, div.Params[0].Schema, map[string]interface{}{"type": "number"})
+	_verifyequal(t, div.Result.Schema["type"], "object")
+
+	update := doc.Methods[1]
+	_verifyequal(t, update.Name, "update")
+	_verifyequal(t, update.Summary, "Does nothing")
+	_verifyequal(t, update.Deprecated, true)
+	_verifyequal(t, len(update.Params), 0)
+	_verifyequal(t, update.Result.Name, "result")
+}
+
+func TestJSONSchema(t *testing.T) {
+	type Node struct {
+		Name     string            `json:"name"`
+		Tags     []string          `json:"tags,omitempty"`
+		Parent   *Node             `json:"parent"`
+		Meta     map[string]int    `json:"meta"`
+		Created  time.Time         `json:"created"`
+		Raw      json.RawMessage   `json:"raw"`
+		Data     []byte            `json:"data"`
+		Ignored  string            `json:"-"`
+		Extra    interface{}       `json:"extra"`
+		internal string            // nolint: structcheck,unused
+		Labels   map[string]string `json:"labels,omitempty"`
+	}
+
+	schema := jsonSchema(reflect.TypeOf(&Node{}))
+
+	_verifyequal(t, schema["type"], "object")
+	_verifyequal(t, schema["required"], []string{"name", "meta", "created", "raw", "data", "extra"})
+
+	properties := schema["properties"].(map[string]interface{})
+
+	_verifyequal(t, len(properties), 9)
+	_verifyequal(t, properties["tags"], map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}})
+	_verifyequal(t, properties["parent"], map[string]interface{}{"type": "object"})
+	_verifyequal(t, properties["meta"], map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "integer"}})
+	_verifyequal(t, properties["created"], map[string]interface{}{"type": "string", "format": "date-time"})
+	_verifyequal(t, properties["raw"], map[string]interface{}{})
+	_verifyequal(t, properties["data"], map[string]interface{}{"type": "string", "contentEncoding": "base64"})
+}
+
+func TestParamsValidator(t *testing.T) {
+	_, err := newParamsValidator([]byte(`{"type": `))
+	_verifyequal(t, err == nil, false)
+
+	_, err = newParamsValidator([]byte(`{"$ref": "#/definitions/x"}`))
+	_verifyequal(t, err == nil, false)
+
+	// unsupported assertion keywords are rejected
+	_, err = newParamsValidator([]byte(`{"properties": {"x": {"type": "array", "uniqueItems": true}}}`))
+	_verifyequal(t, err.Error(), "invalid JSON Schema: /properties/x: keyword 'uniqueItems' is not supported")
+
+	_, err = newParamsValidator([]byte(`{"if": {"required": ["x"]}, "then": {"required": ["y"]}}`))
+	_verifyequal(t, err == nil, false)
+
+	_, err = newParamsValidator([]byte(`{"properties": {"x": {"pattern": "("}}}`))
+	_verifyequal(t, err == nil, false)
+
+	_, err = newParamsValidator([]byte(`[]`))
+	_verifyequal(t, err == nil, false)
+
+	v, err := newParamsValidator([]byte(`{
+		"type": "object",
+		"required": ["name", "age"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 2, "pattern": "^[a-z]+$"},
+			"age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 150},
+			"role": {"enum": ["admin", "user"]},
+			"tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}},
+			"a/b": {"oneOf": [{"type": "string"}, {"type": "number"}]},
+			"any": {"anyOf": [{"type": "null"}, {"type": "boolean"}]},
+			"not": {"not": {"const": 0}}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, v.Validate(json.RawMessage(`{"name": "bob", "age": 42, "role": "user", "tags": ["x"], "a/b": 1, "any": null, "not": 1}`)), []SchemaViolation{})
+
+	_verifyequal(t, v.Validate(json.RawMessage(`{
+		"name": "B",
+		"age": 1.5,
+		"role": "root",
+		"tags": ["x", 2, "z"],
+		"a/b": true,
+		"any": 1,
+		"not": 0,
+		"extra": 1
+	}`)), []SchemaViolation{
+		{Pointer: "/a~1b", Reason: "value must match exactly one schema from oneOf, matched 0"},
+		{Pointer: "/age", Reason: "expected integer, got number"},
+		{Pointer: "/any", Reason: "value does not match any schema from anyOf"},
+		{Pointer: "/extra", Reason: "additional property is not allowed"},
+		{Pointer: "/name", Reason: "length must be greater than or equal to 2"},
+		{Pointer: "/name", Reason: "does not match pattern '^[a-z]+$'"},
+		{Pointer: "/not", Reason: "value must not match schema from not"},
+		{Pointer: "/role", Reason: "value is not one of enumerated values"},
+		{Pointer: "/tags", Reason: "must contain at most 2 items"},
+		{Pointer: "/tags/1", Reason: "expected string, got integer"},
+	})
+
+	_verifyequal(t, v.Validate(json.RawMessage(`{}`)), []SchemaViolation{
+		{Pointer: "/name", Reason: "property is required"},
+		{Pointer: "/age", Reason: "property is required"},
+	})
+
+	_verifyequal(t, v.Validate(nil), []SchemaViolation{
+		{Pointer: "", Reason: "expected object, got null"},
+	})
+}
+
+func TestSetParamsSchema(t *testing.T) {
+	var result Result
+
+	testService := Create("")
+	testService.Register("subtract", Subtract)
+
+	_verifyequal(t, testService.SetParamsSchema("nonexistent", []byte(`{}`)) == nil, false)
+	_verifyequal(t, testService.SetParamsSchema("subtract", []byte(`{"type": 1}`)) == nil, false)
+
+	err := testService.SetParamsSchema("subtract", []byte(`{
+		"type": "object",
+		"required": ["X", "Y"],
+		"properties": {"X": {"type": "number"}, "Y": {"type": "number"}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc": "2.0", "method": "subtract", "params": {"X": "42"}, "id": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	rec := httptest.NewRecorder()
+	testService.ServeHTTP(rec, req)
+
+	if err = json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, result.ID, float64(1))
+	_verifyerrobj(t, result.Error, InvalidParamsCode, InvalidParamsMessage)
+	_verifyequal(t, result.Error.Data, []interface{}{
+		map[string]interface{}{"pointer": "/Y", "reason": "property is required"},
+		map[string]interface{}{"pointer": "/X", "reason": "expected number, got string"},
+	})
+
+	doc := testService.GetOpenRPCDocument()
+	_verifyequal(t, len(doc.Methods[0].Params), 2)
+	_verifyequal(t, doc.Methods[0].Params[0].Name, "X")
+	_verifyequal(t, doc.Methods[0].Params[0].Required, true)
+	_verifyequal(t, doc.Methods[0].ParamStructure, "by-name")
+
+	// re-registered method keeps schema, timeout and descriptor
+	if err = testService.SetMethodTimeoutFor("subtract", time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = testService.SetMethodDescriptor("subtract", MethodDescriptor{Summary: "subtracts"}); err != nil {
+		t.Fatal(err)
+	}
+
+	testService.Register("subtract", Subtract)
+
+	m, _ := testService.getMethod("subtract")
+	_verifyequal(t, m.validator != nil, true)
+	_verifyequal(t, m.timeout, time.Second)
+	_verifyequal(t, m.descriptor.Summary, "subtracts")
+
+	// schema attached at registration time
+	_verifyequal(t, testService.RegisterWithSchema("update", []byte(`{"type": 1}`), Update) == nil, false)
+	_verifyequal(t, testService.HasMethod("update"), false)
+
+	if err = testService.RegisterWithSchema("update", []byte(`{"type": "array", "maxItems": 1}`), Update); err != nil {
+		t.Fatal(err)
+	}
+
+	m, _ = testService.getMethod("update")
+	_verifyequal(t, len(m.validator.Validate(json.RawMessage(`[1, 2]`))), 1)
+}
+
+func TestServeGracefulShutdown(t *testing.T) {
+	sock := "/tmp/jrpc2_shutdown.socket"
+	_ = os.Remove(sock)
+
+	testService := Create(sock)
+	testService.SetShutdownTimeout(time.Second)
+	_verifyequal(t, testService.GetShutdownTimeout(), time.Second)
+
+	started := make(chan struct{})
+
+	testService.Register("slow", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+
+		return "done", nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- testService.Serve(ctx)
+	}()
+
+	// wait for Unix Socket to be created
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if _, err := os.Stat(sock); !os.IsNotExist(err) {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatal("unix socket was not created")
+		}
+
+		time.Sleep(1 * time.Millisecond)
+	}
+
+	respCh := make(chan *http.Response, 1)
+
+	go func() {
+		resp, err := httpPost("http://localhost/", `{"jsonrpc": "2.0", "method": "slow", "id": 1}`, sock, postHeaders)
+		if err != nil {
+			t.Error(err)
+		}
+
+		respCh <- resp
+	}()
+
+	var resp *http.Response
+
+	// shut down while request is in flight
+	select {
+	case <-started:
+		cancel()
+
+		resp = <-respCh
+	case resp = <-respCh:
+		cancel()
+	case <-time.After(5 * time.Second):
+		cancel()
+	}
+
+	if resp == nil {
+		t.FailNow()
+	}
+
+	defer resp.Body.Close()
+
+	var result Result
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, result.Result, "done")
+
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Fatal("expected unix socket file to be removed")
+	}
+
+	// stopped service does not start again
+	_verifyequal(t, testService.Start(), nil)
+	_verifyequal(t, testService.Shutdown(context.Background()), nil)
+}
+
+func TestMiddleware(t *testing.T) {
+	var order []string
+
+	testService := Create("")
+
+	testService.Register("echo", func(params ParametersObject) (interface{}, *ErrorObject) {
+		order = append(order, "method")
+
+		return string(params.GetRawJSONParams()), nil
+	})
+
+	testService.Register("fail", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return nil, &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+		}
+	})
+
+	type call struct {
+		name   string
+		result interface{}
+		errObj *ErrorObject
+	}
+
+	var calls []call
+
+	// logging middleware, observes method name, result and error
+	testService.Use(func(next Handler) Handler {
+		return func(data ParametersObject) (interface{}, *ErrorObject) {
+			order = append(order, "log")
+
+			result, errObj := next(data)
+			calls = append(calls, call{data.GetMethodName(), result, errObj})
+
+			return result, errObj
+		}
+	})
+
+	// authorization middleware, short-circuits without calling method
+	testService.Use(func(next Handler) Handler {
+		return func(data ParametersObject) (interface{}, *ErrorObject) {
+			order = append(order, "auth")
+
+			if string(data.GetRawJSONParams()) == `"deny"` {
+				return nil, &ErrorObject{
+					Code:    -32001,
+					Message: "Forbidden",
+				}
+			}
+
+			if data.GetContext() == nil {
+				t.Error("expected method context")
+			}
+
+			return next(data)
+		}
+	})
+
+	result, errObj := testService.Call("echo", ParametersObject{method: "echo", params: json.RawMessage(`"ok"`)})
+	_verifyequal(t, result, `"ok"`)
+	_verifyequal(t, errObj == nil, true)
+	_verifyequal(t, order, []string{"log", "auth", "method"})
+
+	order = nil
+
+	result, errObj = testService.Call("echo", ParametersObject{method: "echo", params: json.RawMessage(`"deny"`)})
+	_verifyequal(t, result, nil)
+	_verifyerrobj(t, errObj, -32001, "Forbidden")
+	_verifyequal(t, order, []string{"log", "auth"})
+
+	_, errObj = testService.Call("fail", ParametersObject{method: "fail"})
+	_verifyerrobj(t, errObj, InvalidParamsCode, InvalidParamsMessage)
+
+	_verifyequal(t, len(calls), 3)
+	_verifyequal(t, calls[0].name, "echo")
+	_verifyequal(t, calls[0].result, `"ok"`)
+	_verifyequal(t, calls[1].errObj.Code, -32001)
+	_verifyequal(t, calls[2].name, "fail")
+	_verifyequal(t, calls[2].errObj.Code, InvalidParamsCode)
+
+	// unknown method does not reach middleware
+	calls = nil
+
+	_, errObj = testService.Call("unknown", ParametersObject{method: "unknown"})
+	_verifyerrobj(t, errObj, MethodNotFoundCode, MethodNotFoundMessage)
+	_verifyequal(t, len(calls), 0)
+
+	// middleware panic is recovered
+	testService.Use(func(next Handler) Handler {
+		return func(data ParametersObject) (interface{}, *ErrorObject) {
+			panic("middleware")
+		}
+	})
+
+	_, errObj = testService.Call("echo", ParametersObject{method: "echo"})
+	_verifyerrobj(t, errObj, InternalErrorCode, InternalErrorMessage)
+}
+
+func TestMethodAndNamespaceMiddleware(t *testing.T) {
+	var order []string
+
+	testService := Create("")
+
+	for _, name := range []string{"admin.reset", "admin.stats", "user.get"} {
+		name := name
+
+		testService.Register(name, func(_ ParametersObject) (interface{}, *ErrorObject) {
+			order = append(order, name)
+
+			return name, nil
+		})
+	}
+
+	track := func(label string) Middleware {
+		return func(next Handler) Handler {
+			return func(data ParametersObject) (interface{}, *ErrorObject) {
+				order = append(order, label)
+
+				return next(data)
+			}
+		}
+	}
+
+	testService.UseMethod("admin.reset", track("reset"))
+	testService.UseNamespace("admin.", track("admin"))
+	testService.Use(track("global"))
+
+	// audit denies access for whole namespace
+	testService.UseNamespace("admin.", func(next Handler) Handler {
+		return func(data ParametersObject) (interface{}, *ErrorObject) {
+			if string(data.GetRawJSONParams()) != `"secret"` {
+				return nil, &ErrorObject{
+					Code:    -32001,
+					Message: "Forbidden",
+				}
+			}
+
+			return next(data)
+		}
+	})
+
+	call := func(name, params string) (interface{}, *ErrorObject) {
+		order = nil
+
+		return testService.Call(name, ParametersObject{method: name, params: json.RawMessage(params)})
+	}
+
+	result, errObj := call("user.get", `null`)
+	_verifyequal(t, result, "user.get")
+	_verifyequal(t, errObj == nil, true)
+	_verifyequal(t, order, []string{"global", "user.get"})
+
+	result, errObj = call("admin.stats", `"secret"`)
+	_verifyequal(t, result, "admin.stats")
+	_verifyequal(t, errObj == nil, true)
+	_verifyequal(t, order, []string{"global", "admin", "admin.stats"})
+
+	result, errObj = call("admin.reset", `"secret"`)
+	_verifyequal(t, result, "admin.reset")
+	_verifyequal(t, errObj == nil, true)
+	_verifyequal(t, order, []string{"global", "admin", "reset", "admin.reset"})
+
+	_, errObj = call("admin.reset", `null`)
+	_verifyerrobj(t, errObj, -32001, "Forbidden")
+	_verifyequal(t, order, []string{"global", "admin"})
+}
+
+func TestHooks(t *testing.T) {
+	var order []string
+
+	testService := Create("")
+
+	testService.Register("echo", func(params ParametersObject) (interface{}, *ErrorObject) {
+		return string(params.GetRawJSONParams()), nil
+	})
+
+	testService.SetRequestHookFunction(func(_ *http.Request, _ []byte) error {
+		order = append(order, "request function")
+
+		return nil
+	})
+
+	// rewrites request params
+	testService.AddRequestHook(
+		func(_ *http.Request, data []byte) ([]byte, error) {
+			order = append(order, "request 1")
+
+			return []byte(strings.Replace(string(data), `"params": "a"`, `"params": "b"`, 1)), nil
+		},
+		func(_ *http.Request, data []byte) ([]byte, error) {
+			order = append(order, "request 2")
+
+			if strings.Contains(string(data), `"params": "a"`) {
+				t.Error("expected rewritten request body")
+			}
+
+			return nil, nil
+		},
+	)
+
+	// rewrites response result
+	testService.AddResponseHook(func(_ *http.Request, data []byte) ([]byte, error) {
+		order = append(order, "response")
+
+		return []byte(strings.Replace(string(data), `"\"b\""`, `"\"c\""`, 1)), nil
+	})
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		order = nil
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		rec := httptest.NewRecorder()
+		testService.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := serve(`{"jsonrpc": "2.0", "method": "echo", "params": "a", "id": 1}`)
+
+	var result Result
+
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, result.Result, `"c"`)
+	_verifyequal(t, order, []string{"request function", "request 1", "request 2", "response"})
+
+	// failed hook stops the rest of hooks
+	testService.AddRequestHook(func(_ *http.Request, _ []byte) ([]byte, error) {
+		return nil, NewHookError("denied", http.StatusForbidden)
+	})
+
+	testService.AddRequestHook(func(_ *http.Request, data []byte) ([]byte, error) {
+		t.Error("expected hook not to be called")
+
+		return data, nil
+	})
+
+	rec = serve(`{"jsonrpc": "2.0", "method": "echo", "params": "a", "id": 1}`)
+	_verifyequal(t, rec.Code, http.StatusForbidden)
+	_verifyequal(t, order, []string{"request function", "request 1", "request 2"})
+}
+
+func TestHookErrorResponse(t *testing.T) {
+	testService := Create("")
+
+	testService.Register("echo", func(params ParametersObject) (interface{}, *ErrorObject) {
+		return string(params.GetRawJSONParams()), nil
+	})
+
+	serve := func(body string) (*httptest.ResponseRecorder, Result) {
+		var result Result
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		rec := httptest.NewRecorder()
+		testService.ServeHTTP(rec, req)
+
+		if rec.Body.Len() > 0 {
+			if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return rec, result
+	}
+
+	testService.AddRequestHook(func(_ *http.Request, data []byte) ([]byte, error) {
+		if strings.Contains(string(data), "deny") {
+			return nil, NewHookError("request denied", http.StatusUnavailableForLegalReasons)
+		}
+
+		return nil, nil
+	})
+
+	testService.AddResponseHook(func(_ *http.Request, data []byte) ([]byte, error) {
+		if strings.Contains(string(data), "hide") {
+			return nil, fmt.Errorf("response denied")
+		}
+
+		return nil, nil
+	})
+
+	// disabled by default, status code only
+	_verifyequal(t, testService.GetHookErrorResponse(), false)
+	_verifyequal(t, testService.GetHookErrorCode(), HookErrorCode)
+
+	rec, _ := serve(`{"jsonrpc": "2.0", "method": "echo", "params": "deny", "id": 1}`)
+	_verifyequal(t, rec.Code, http.StatusUnavailableForLegalReasons)
+	_verifyequal(t, rec.Body.Len(), 0)
+
+	testService.SetHookErrorResponse(true)
+
+	rec, result := serve(`{"jsonrpc": "2.0", "method": "echo", "params": "deny", "id": 1}`)
+	_verifyequal(t, rec.Code, http.StatusUnavailableForLegalReasons)
+	_verifyequal(t, rec.Header().Get("Content-Type"), "application/json")
+	_verifyequal(t, result.ID, float64(1))
+	_verifyerrobj(t, result.Error, HookErrorCode, HookErrorMessage)
+	_verifyequal(t, result.Error.Data, "request denied")
+
+	testService.SetHookErrorCode(-32099)
+
+	rec, result = serve(`{"jsonrpc": "2.0", "method": "echo", "params": "hide", "id": "ID:42"}`)
+	_verifyequal(t, rec.Code, http.StatusInternalServerError)
+	_verifyequal(t, result.ID, "ID:42")
+	_verifyerrobj(t, result.Error, -32099, HookErrorMessage)
+	_verifyequal(t, result.Error.Data, "response denied")
+}
+
+func TestDynamicRegistration(t *testing.T) {
+	testService := Create("")
+
+	handler := func(params ParametersObject) (interface{}, *ErrorObject) {
+		return params.GetMethodName(), nil
+	}
+
+	testService.Register("b", handler)
+	testService.Register("a", handler)
+
+	_verifyequal(t, testService.HasMethod("a"), true)
+	_verifyequal(t, testService.HasMethod("c"), false)
+	_verifyequal(t, testService.GetMethods(), []string{"a", "b"})
+
+	_verifyequal(t, testService.Unregister("a"), true)
+	_verifyequal(t, testService.Unregister("a"), false)
+	_verifyequal(t, testService.GetMethods(), []string{"b"})
+
+	_, errObj := testService.Call("a", ParametersObject{method: "a"})
+	_verifyerrobj(t, errObj, MethodNotFoundCode, MethodNotFoundMessage)
+
+	var wg sync.WaitGroup
+
+	// register, unregister and call concurrently, verified by race detector
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+
+		name := fmt.Sprintf("plugin.%d", i)
+
+		go func() {
+			defer wg.Done()
+
+			testService.Register(name, handler)
+			_ = testService.SetMethodTimeoutFor(name, time.Second)
+			_ = testService.SetParamsSchema(name, []byte(`{}`))
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			_ = testService.HasMethod(name)
+			_ = testService.GetMethods()
+			_ = testService.GetOpenRPCDocument()
+			_, _ = testService.Call(name, ParametersObject{method: name})
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			_ = testService.Unregister(name)
+		}()
+	}
+
+	wg.Wait()
+
+	result, errObj := testService.Call("b", ParametersObject{method: "b"})
+	_verifyequal(t, errObj == nil, true)
+	_verifyequal(t, result, "b")
+}
+
+func TestMethodAliases(t *testing.T) {
+	testService := Create("")
+
+	testService.Register(VersionedMethodName("user.get", "v1"), func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "v1", nil
+	})
+
+	testService.Register(VersionedMethodName("user.get", "v2"), func(params ParametersObject) (interface{}, *ErrorObject) {
+		if string(params.GetRawJSONParams()) == `"fail"` {
+			return nil, &ErrorObject{
+				Code:    InvalidParamsCode,
+				Message: InvalidParamsMessage,
+				Data:    "bad user",
+			}
+		}
+
+		return "v2", nil
+	})
+
+	_verifyequal(t, testService.RegisterAlias("user.get", "user.get@v2", false), nil)
+	_verifyequal(t, testService.RegisterAlias("getUser", "user.get@v1", true), nil)
+	_verifyequal(t, testService.RegisterAlias("user.find", "user.missing", false) != nil, true)
+	_verifyequal(t, testService.RegisterAlias("user.get@v1", "user.get@v2", false) != nil, true)
+	_verifyequal(t, testService.RegisterAlias("rpc.user", "user.get@v2", false) != nil, true)
+
+	serve := func(body string) (*httptest.ResponseRecorder, string) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		rec := httptest.NewRecorder()
+		testService.ServeHTTP(rec, req)
+
+		return rec, strings.TrimSpace(rec.Body.String())
+	}
+
+	rec, body := serve(`{"jsonrpc": "2.0", "method": "user.get", "id": 1}`)
+	_verifyequal(t, body, `{"jsonrpc":"2.0","result":"v2","id":1}`)
+	_verifyequal(t, rec.Header().Get(DeprecationHeader), "")
+
+	rec, body = serve(`{"jsonrpc": "2.0", "method": "getUser", "id": 2}`)
+	_verifyequal(t, body, `{"jsonrpc":"2.0","result":"v1","id":2}`)
+	_verifyequal(t, rec.Header().Get(DeprecationHeader), "true")
+	_verifyequal(t, rec.Header().Get("Content-Type"), "application/json")
+
+	rec, _ = serve(`[{"jsonrpc": "2.0", "method": "user.get@v1", "id": 3}, {"jsonrpc": "2.0", "method": "getUser", "id": 4}]`)
+	_verifyequal(t, rec.Header().Get(DeprecationHeader), "true")
+
+	// deprecation signal in error data
+	testService.SetDeprecationErrorData(true)
+	_verifyequal(t, testService.RegisterAlias("getUserV2", "user.get@v2", true), nil)
+
+	_, errObj := testService.Call("getUserV2", ParametersObject{method: "getUserV2", params: json.RawMessage(`"fail"`)})
+	_verifyerrobj(t, errObj, InvalidParamsCode, InvalidParamsMessage)
+	_verifyequal(t, errObj.Data, DeprecationData{
+		Deprecated: true,
+		Alias:      "getUserV2",
+		Method:     "user.get@v2",
+		Data:       "bad user",
+	})
+
+	// error data of non-deprecated alias is unchanged
+	_, errObj = testService.Call("user.get", ParametersObject{method: "user.get", params: json.RawMessage(`"fail"`)})
+	_verifyequal(t, errObj.Data, "bad user")
+
+	_verifyequal(t, testService.GetAliasCallCounts(), map[string]uint64{
+		"user.get":  2,
+		"getUser":   2,
+		"getUserV2": 1,
+	})
+
+	// aliases are described in service discovery document
+	doc := testService.GetOpenRPCDocument()
+	names := make([]string, 0, len(doc.Methods))
+
+	for _, el := range doc.Methods {
+		names = append(names, el.Name)
+
+		if el.Name == "getUser" {
+			_verifyequal(t, el.Deprecated, true)
+		}
+	}
+
+	_verifyequal(t, names, []string{"getUser", "getUserV2", "user.get", "user.get@v1", "user.get@v2"})
+
+	_verifyequal(t, testService.UnregisterAlias("getUser"), true)
+	_verifyequal(t, testService.UnregisterAlias("getUser"), false)
+
+	_, errObj = testService.Call("getUser", ParametersObject{method: "getUser"})
+	_verifyerrobj(t, errObj, MethodNotFoundCode, MethodNotFoundMessage)
+}
+
+func TestMethodVersions(t *testing.T) {
+	testService := Create("")
+
+	for _, version := range []string{"v1", "v2", "v10"} {
+		version := version
+
+		testService.Register(VersionedMethodName("sum", version), func(_ ParametersObject) (interface{}, *ErrorObject) {
+			return version, nil
+		})
+	}
+
+	name, version := SplitVersionedMethodName("sum@v10")
+	_verifyequal(t, name, "sum")
+	_verifyequal(t, version, "v10")
+
+	name, version = SplitVersionedMethodName("sum")
+	_verifyequal(t, name, "sum")
+	_verifyequal(t, version, "")
+
+	call := func(name string) interface{} {
+		result, errObj := testService.Call(name, ParametersObject{method: name})
+		if errObj != nil {
+			return errObj.Code
+		}
+
+		return result
+	}
+
+	// unversioned name resolves to latest version
+	_verifyequal(t, call("sum"), "v10")
+	_verifyequal(t, call("sum@v2"), "v2")
+	_verifyequal(t, call("sum@v3"), MethodNotFoundCode)
+	_verifyequal(t, call("mul"), MethodNotFoundCode)
+
+	// alias pins unversioned name
+	_verifyequal(t, testService.RegisterAlias("sum", "sum@v1", false), nil)
+	_verifyequal(t, call("sum"), "v1")
+
+	// middleware of target method and of called alias applies
+	marker := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(data ParametersObject) (interface{}, *ErrorObject) {
+				result, errObj := next(data)
+
+				return fmt.Sprintf("%s(%v)", name, result), errObj
+			}
+		}
+	}
+
+	testService.UseMethod("sum@v1", marker("target"))
+	testService.UseMethod("sum", marker("alias"))
+
+	_verifyequal(t, call("sum"), "target(alias(v1))")
+	_verifyequal(t, call("sum@v1"), "target(v1)")
+
+	// calls resolved to sequential method keep request order inside concurrent batch
+	var (
+		mu    sync.Mutex
+		order []int
+	)
+
+	testService.Register(VersionedMethodName("log", "v1"), func(params ParametersObject) (interface{}, *ErrorObject) {
+		n, _ := strconv.Atoi(string(params.GetRawJSONParams()))
+		time.Sleep(time.Duration(5-n) * 10 * time.Millisecond)
+
+		mu.Lock()
+		order = append(order, n)
+		mu.Unlock()
+
+		return n, nil
+	})
+
+	testService.SetBatchConcurrency(5)
+	testService.SetSequentialMethods([]string{"log@v1"})
+
+	batch := make([]string, 0, 5)
+	for i := 1; i <= 5; i++ {
+		batch = append(batch, fmt.Sprintf(`{"jsonrpc": "2.0", "method": "log", "params": %d, "id": %d}`, i, i))
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("["+strings.Join(batch, ",")+"]"))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	testService.ServeHTTP(httptest.NewRecorder(), req)
+
+	_verifyequal(t, order, []int{1, 2, 3, 4, 5})
+}
+
+func TestEndpoints(t *testing.T) {
+	testService := CreateOverTCP("127.0.0.1:0", "/public")
+	testService.Register("whoami", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "public", nil
+	})
+
+	admin, err := testService.AddEndpoint("/admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testService.AddEndpoint("/admin")
+	_verifyequal(t, err != nil, true)
+
+	_, err = testService.AddEndpoint("/public")
+	_verifyequal(t, err != nil, true)
+
+	// unnormalized routes are normalized before collision check
+	_, err = testService.AddEndpoint("admin")
+	_verifyequal(t, err != nil, true)
+
+	_, err = testService.AddEndpoint(" public ")
+	_verifyequal(t, err != nil, true)
+
+	root, err := testService.AddEndpoint("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, root.GetRoute(), "/")
+	_verifyequal(t, testService.GetEndpoint("/") == root, true)
+
+	ops, err := testService.AddEndpoint("ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, ops.GetRoute(), "/ops")
+	_verifyequal(t, testService.GetEndpoint("/ops") == ops, true)
+
+	_verifyequal(t, testService.GetEndpoint("/admin") == admin, true)
+	_verifyequal(t, testService.GetEndpoint("/missing") == nil, true)
+
+	admin.Register("whoami", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "admin", nil
+	})
+
+	admin.SetHeaders(map[string]string{
+		"X-Endpoint": "admin",
+	})
+
+	if err = admin.AddAuthorization("root", "secret", []string{"127.0.0.1/32"}); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- testService.ServeListener(l)
+	}()
+
+	post := func(route string, auth bool) (*http.Response, Result) {
+		var result Result
+
+		req, err := http.NewRequest(http.MethodPost, "http://"+l.Addr().String()+route, strings.NewReader(`{"jsonrpc": "2.0", "method": "whoami", "id": 1}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		if auth {
+			req.SetBasicAuth("root", "secret")
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusOK {
+			if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return resp, result
+	}
+
+	resp, result := post("/public", false)
+	_verifyequal(t, result.Result, "public")
+	_verifyequal(t, resp.Header.Get("X-Endpoint"), "")
+
+	resp, _ = post("/admin", false)
+	_verifyequal(t, resp.StatusCode, http.StatusForbidden)
+
+	resp, result = post("/admin", true)
+	_verifyequal(t, result.Result, "admin")
+	_verifyequal(t, resp.Header.Get("X-Endpoint"), "admin")
+
+	// endpoints with unnormalized routes are served
+	resp, result = post("/ops", false)
+	_verifyequal(t, resp.StatusCode, http.StatusOK)
+	_verifyerrobj(t, result.Error, MethodNotFoundCode, MethodNotFoundMessage)
+
+	resp, _ = post("/other", false)
+	_verifyequal(t, resp.StatusCode, http.StatusOK)
+
+	// endpoint accepts HTTP versions of parent service
+	if err = testService.SetHTTPVersions("1.0", "1.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, admin.GetHTTPVersions(), []string{"HTTP/1.0", "HTTP/1.1"})
+
+	req := httptest.NewRequest(http.MethodPost, "/admin", strings.NewReader(`{"jsonrpc": "2.0", "method": "whoami", "id": 1}`))
+	req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/1.0", 1, 0
+	req.RemoteAddr = "127.0.0.1:1234"
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth("root", "secret")
+
+	rec := httptest.NewRecorder()
+	testService.HTTPHandler().ServeHTTP(rec, req)
+	_verifyequal(t, rec.Code, http.StatusOK)
+
+	// endpoint connections are closed on parent shutdown
+	admin.SetWebSocket(true)
+
+	header := http.Header{}
+	header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("root:secret")))
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws://"+l.Addr().String()+"/admin", header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer ws.Close()
+
+	if err = testService.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, <-errCh, nil)
+
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	start := time.Now()
+
+	_, _, err = ws.ReadMessage()
+	_verifyequal(t, err != nil, true)
+	_verifyequal(t, time.Since(start) < 4*time.Second, true)
+}
+
+func TestCreateHandler(t *testing.T) {
+	testService := CreateHandler("/rpc")
+	testService.Register("update", Update)
+
+	_verifyequal(t, testService.GetSocket(), "")
+	_verifyequal(t, testService.GetAddress(), "")
+	_verifyequal(t, testService.GetCertificateFilePath(), "")
+	_verifyequal(t, testService.Start() != nil, true)
+	_verifyequal(t, testService.StartTCP() != nil, true)
+
+	admin, err := testService.AddEndpoint("/admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin.Register("update", Update)
+
+	// limit is matched against URL path without mount prefix
+	testService.SetRouteMaxRequestSize("/rpc", 10)
+
+	post := func(h http.Handler, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"jsonrpc": "2.0", "method": "update", "id": 1}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	// mounted on application multiplexer
+	mux := http.NewServeMux()
+	testService.Mount(mux)
+
+	_verifyequal(t, post(mux, "/rpc").Code, http.StatusRequestEntityTooLarge)
+	_verifyequal(t, post(mux, "/admin").Code, http.StatusOK)
+	_verifyequal(t, post(mux, "/other").Code, http.StatusNotFound)
+
+	// mounted under path prefix
+	app := http.NewServeMux()
+	app.Handle("/api/", http.StripPrefix("/api", testService.HTTPHandler()))
+
+	_verifyequal(t, post(app, "/api/rpc").Code, http.StatusRequestEntityTooLarge)
+	_verifyequal(t, post(app, "/api/admin").Code, http.StatusOK)
+	_verifyequal(t, post(app, "/rpc").Code, http.StatusNotFound)
+
+	// route is normalized same as SetRoute
+	_verifyequal(t, CreateHandler("rpc").GetRoute(), "/rpc")
+	_verifyequal(t, CreateHandler(" ").GetRoute(), "/")
+
+	mux = http.NewServeMux()
+	CreateHandler("rpc").Mount(mux)
+
+	_verifyequal(t, post(mux, "/rpc").Code, http.StatusOK)
+	_verifyequal(t, post(CreateHandler("").HTTPHandler(), "/").Code, http.StatusOK)
+}
+
+func TestWebSocket(t *testing.T) {
+	testService := CreateOverTCP("127.0.0.1:0", "/rpc")
+	testService.SetWebSocket(true)
+
+	release := make(chan struct{})
+
+	testService.Register("slow", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		<-release
+
+		return "slow", nil
+	})
+
+	testService.Register("fast", func(params ParametersObject) (interface{}, *ErrorObject) {
+		defer close(release)
+
+		// server notification before response
+		if err := params.GetConn().Notify("progress", []int{1, 2}); err != nil {
+			t.Error(err)
+		}
+
+		return "fast", nil
+	})
+
+	testService.Register("plain", func(params ParametersObject) (interface{}, *ErrorObject) {
+		return params.GetConn() == nil, nil
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- testService.ServeListener(l)
+	}()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws://"+l.Addr().String()+"/rpc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer ws.Close()
+
+	// many requests over one connection
+	for _, msg := range []string{
+		`{"jsonrpc": "2.0", "method": "slow", "id": 1}`,
+		`{"jsonrpc": "2.0", "method": "fast", "id": 2}`,
+		`{"jsonrpc": "2.0", "method": "plain"}`,
+		`[{"jsonrpc": "2.0", "method": "plain", "id": 3}]`,
+		`{"jsonrpc": "2.0", "method": "unknown", "id": 4}`,
+		`{"jsonrpc": "2.0", "method"`,
+	} {
+		if err = ws.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	messages := make(map[string]string)
+
+	var order []string
+
+	for i := 0; i < 6; i++ {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var msg struct {
+			ID     interface{}     `json:"id"`
+			Method string          `json:"method"`
+			Result json.RawMessage `json:"result"`
+			Error  *ErrorObject    `json:"error"`
+		}
+
+		if err = json.Unmarshal(data, &msg); err != nil {
+			// batch response
+			messages["batch"] = string(data)
+
+			continue
+		}
+
+		key := fmt.Sprint(msg.ID)
+		if msg.Method != "" {
+			key = msg.Method
+		}
+
+		order = append(order, key)
+		messages[key] = string(data)
+	}
+
+	_verifyequal(t, messages["progress"], `{"jsonrpc":"2.0","method":"progress","params":[1,2]}`)
+	_verifyequal(t, messages["2"], `{"jsonrpc":"2.0","result":"fast","id":2}`)
+	_verifyequal(t, messages["1"], `{"jsonrpc":"2.0","result":"slow","id":1}`)
+	_verifyequal(t, messages["batch"], `[{"jsonrpc":"2.0","result":false,"id":3}]`)
+	_verifyequal(t, strings.Contains(messages["4"], `"code":-32601`), true)
+	_verifyequal(t, strings.Contains(messages["<nil>"], `"code":-32700`), true)
+
+	// notification precedes response of the same call, slow response arrives after fast one
+	pos := make(map[string]int)
+	for i, el := range order {
+		pos[el] = i
+	}
+
+	_verifyequal(t, pos["progress"] < pos["2"], true)
+	_verifyequal(t, pos["2"] < pos["1"], true)
+
+	// plain HTTP requests are served on the same route
+	req, err := http.NewRequest(http.MethodPost, "http://"+l.Addr().String()+"/rpc", strings.NewReader(`{"jsonrpc": "2.0", "method": "plain", "id": 5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	var result Result
+
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, result.Result, true)
+
+	// shutdown closes WebSocket connections
+	if err = testService.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, <-errCh, nil)
+
+	if _, _, err = ws.ReadMessage(); err == nil {
+		t.Fatal("expected closed connection")
+	}
+}
+
+func TestSubscriptions(t *testing.T) {
+	testService := CreateOverTCP("127.0.0.1:0", "/rpc")
+	testService.SetWebSocket(true)
+
+	var (
+		conns    = make(chan *Conn, 2)
+		stopped  = make(chan string, 2)
+		producer = make(chan interface{})
+	)
+
+	testService.RegisterSubscription("subscribe", "unsubscribe", func(ctx context.Context, params ParametersObject) (<-chan interface{}, *ErrorObject) {
+		topic, errObj := GetPositionalStringParams(params)
+		if errObj != nil {
+			return nil, errObj
+		}
+
+		conns <- params.GetConn()
+
+		// producer stops when subscription is cancelled
+		go func() {
+			<-ctx.Done()
+			stopped <- topic[0]
+		}()
+
+		return producer, nil
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- testService.ServeListener(l)
+	}()
+
+	// subscriptions require persistent connection
+	_, errObj := testService.Call("subscribe", ParametersObject{method: "subscribe", params: json.RawMessage(`["status"]`)})
+	_verifyerrobj(t, errObj, NotImplementedCode, NotImplementedMessage)
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws://"+l.Addr().String()+"/rpc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer ws.Close()
+
+	type message struct {
+		ID     interface{}              `json:"id"`
+		Method string                   `json:"method"`
+		Params SubscriptionNotification `json:"params"`
+		Result interface{}              `json:"result"`
+	}
+
+	call := func(msg string) {
+		if err := ws.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	read := func() message {
+		var msg message
+
+		if err := ws.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+
+		return msg
+	}
+
+	call(`{"jsonrpc": "2.0", "method": "subscribe", "params": ["status"], "id": 1}`)
+
+	// events are sent after subscription ID, producer blocks until response is written
+	go func() {
+		producer <- "up"
+		producer <- map[string]int{"load": 1}
+	}()
+
+	msg := read()
+	_verifyequal(t, msg.ID, float64(1))
+
+	id, ok := msg.Result.(string)
+	if !ok || !strings.HasPrefix(id, "0x") {
+		t.Fatalf("unexpected subscription ID '%v'", msg.Result)
+	}
+
+	msg = read()
+	_verifyequal(t, msg.Method, SubscriptionNotificationMethod)
+	_verifyequal(t, msg.Params.Subscription, id)
+	_verifyequal(t, msg.Params.Result, "up")
+
+	msg = read()
+	_verifyequal(t, msg.Params.Result, map[string]interface{}{"load": float64(1)})
+
+	conn := <-conns
+	_verifyequal(t, conn.GetSubscriptions(), 1)
+
+	call(`{"jsonrpc": "2.0", "method": "unsubscribe", "params": ["` + id + `"], "id": 2}`)
+
+	msg = read()
+	_verifyequal(t, msg.Result, true)
+	_verifyequal(t, <-stopped, "status")
+	_verifyequal(t, conn.GetSubscriptions(), 0)
+
+	call(`{"jsonrpc": "2.0", "method": "unsubscribe", "params": ["` + id + `"], "id": 3}`)
+
+	msg = read()
+	_verifyequal(t, msg.Result, false)
+
+	// connection close cancels subscriptions
+	call(`{"jsonrpc": "2.0", "method": "subscribe", "params": ["other"], "id": 4}`)
+
+	msg = read()
+	_verifyequal(t, msg.ID, float64(4))
+
+	if err = ws.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, <-stopped, "other")
+
+	if err = testService.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, <-errCh, nil)
+}
+
+func TestStreamTransport(t *testing.T) {
+	testService := Create("")
+	testService.SetMaxRequestSize(128)
+
+	release := make(chan struct{})
+
+	testService.Register("slow", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		<-release
+
+		return "slow", nil
+	})
+
+	testService.Register("fast", func(params ParametersObject) (interface{}, *ErrorObject) {
+		defer close(release)
+
+		if err := params.GetConn().Notify("progress", nil); err != nil {
+			t.Error(err)
+		}
+
+		return params.GetRemoteAddress() != "", nil
+	})
+
+	testService.Register("ping", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "pong", nil
+	})
+
+	sock := "/tmp/jrpc2_stream.socket"
+	_ = os.Remove(sock)
+
+	ul, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errCh := make(chan error, 2)
+
+	go func() {
+		errCh <- testService.ServeStream(ul, NewlineFraming)
+	}()
+
+	go func() {
+		errCh <- testService.ServeStream(tl, ContentLengthFraming)
+	}()
+
+	// newline-delimited JSON over unix socket, pipelined requests
+	uc, err := net.Dial("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = uc.Write([]byte(
+		`{"jsonrpc": "2.0", "method": "slow", "id": 1}` + "\n" +
+			"\n" +
+			`{"jsonrpc": "2.0", "method": "fast", "id": 2}` + "\n" +
+			`{"jsonrpc": "2.0", "method": "fast", "params": "` + strings.Repeat("x", 128) + `", "id": 3}` + "\n" +
+			`[{"jsonrpc": "2.0", "method": "unknown", "id": 4}]`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// client finished sending, responses are still delivered
+	if err = uc.(*net.UnixConn).CloseWrite(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(uc)
+
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	_verifyequal(t, len(lines), 5)
+
+	sorted := append([]string(nil), lines...)
+	sort.Strings(sorted)
+
+	_verifyequal(t, sorted, []string{
+		`[{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":4}]`,
+		`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"message must not exceed 128 bytes"}}`,
+		`{"jsonrpc":"2.0","method":"progress","params":null}`,
+		`{"jsonrpc":"2.0","result":"slow","id":1}`,
+		`{"jsonrpc":"2.0","result":true,"id":2}`,
+	})
+
+	pos := make(map[string]int)
+	for i, el := range lines {
+		pos[el] = i
+	}
+
+	// fast response arrives before slow one
+	_verifyequal(t, pos[`{"jsonrpc":"2.0","result":true,"id":2}`] < pos[`{"jsonrpc":"2.0","result":"slow","id":1}`], true)
+
+	_ = uc.Close()
+
+	// Content-Length framing over TCP
+	tc, err := net.Dial("tcp", tl.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tc.Close()
+
+	body := `{"jsonrpc": "2.0", "method": "ping", "id": "a"}`
+
+	if _, err = fmt.Fprintf(tc, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		t.Fatal(err)
+	}
+
+	br := bufio.NewReader(tc)
+
+	header, err := br.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, header, "Content-Length: 42\r\n")
+
+	if _, err = br.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := make([]byte, 42)
+	if _, err = io.ReadFull(br, resp); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(resp), `{"jsonrpc":"2.0","result":"pong","id":"a"}`)
+
+	// shutdown waits for in-flight calls, then closes listeners and connections
+	hold, held := make(chan struct{}), make(chan struct{})
+
+	testService.Register("hold", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		close(held)
+		<-hold
+
+		return "held", nil
+	})
+
+	body = `{"jsonrpc": "2.0", "method": "hold", "id": "b"}`
+
+	if _, err = fmt.Fprintf(tc, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		t.Fatal(err)
+	}
+
+	<-held
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- testService.Shutdown(context.Background())
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected shutdown to wait for in-flight call")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(hold)
+
+	if _, err = br.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = br.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = make([]byte, 42)
+	if _, err = io.ReadFull(br, resp); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(resp), `{"jsonrpc":"2.0","result":"held","id":"b"}`)
+
+	_verifyequal(t, <-done, nil)
+	_verifyequal(t, <-errCh, nil)
+	_verifyequal(t, <-errCh, nil)
+
+	if _, err = br.ReadByte(); err == nil {
+		t.Fatal("expected closed connection")
+	}
+
+	_verifyequal(t, testService.ServeStream(tl, Framing(42)) != nil, true)
+
+	// stream transport can not check authorization
+	authService := Create("")
+
+	if err = authService.AddAuthorization("root", "secret", []string{"127.0.0.1/32"}); err != nil {
+		t.Fatal(err)
+	}
+
+	al, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, authService.ServeStream(al, NewlineFraming) != nil, true)
+
+	// every stream entry point refuses service with authorization
+	conn, peer := net.Pipe()
+	defer peer.Close()
+
+	_verifyequal(t, authService.ServeIO(conn, conn, NewlineFraming) != nil, true)
+
+	// refused stream is closed
+	_, err = peer.Read(make([]byte, 1))
+	_verifyequal(t, err, io.EOF)
+}
+
+// stdioHelperProcess serves test methods over stdio, it is run by TestMain inside subprocess spawned by TestStdio.
+func stdioHelperProcess(framing string) {
+	testService := Create("")
+
+	testService.Register("sum", func(params ParametersObject) (interface{}, *ErrorObject) {
+		nums, errObj := GetPositionalIntParams(params)
+		if errObj != nil {
+			return nil, errObj
+		}
+
+		if err := params.GetConn().Notify("summing", len(nums)); err != nil {
+			return nil, &ErrorObject{
+				Code:    InternalErrorCode,
+				Message: InternalErrorMessage,
+				Data:    err.Error(),
+			}
+		}
+
+		sum := 0
+		for _, el := range nums {
+			sum += el
+		}
+
+		return sum, nil
+	})
+
+	f, _ := strconv.Atoi(framing)
+
+	if err := testService.ServeStdio(Framing(f)); err != nil {
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+func TestStdio(t *testing.T) {
+	for _, framing := range []Framing{NewlineFraming, ContentLengthFraming} {
+		cmd := exec.Command(os.Args[0])
+		cmd.Env = append(os.Environ(), fmt.Sprintf("JRPC2_STDIO_FRAMING=%d", framing))
+
+		c, err := client.StartProcess(cmd, client.Framing(framing))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		notifications := make(chan string, 10)
+
+		c.SetNotificationHandler(func(method string, params json.RawMessage) {
+			notifications <- method + " " + string(params)
+		})
+
+		var wg sync.WaitGroup
+
+		// concurrent calls over single subprocess
+		for i := 1; i <= 5; i++ {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				result, err := c.Call("sum", json.RawMessage(fmt.Sprintf("[%d, %d]", i, i)))
+				if err != nil {
+					t.Error(err)
+
+					return
+				}
+
+				_verifyequal(t, string(result), strconv.Itoa(2*i))
+			}(i)
+		}
+
+		wg.Wait()
+
+		_verifyequal(t, <-notifications, "summing 2")
+
+		_, err = c.Call("missing", nil)
+
+		errObj, ok := err.(*client.ErrorObject)
+		if !ok {
+			t.Fatalf("unexpected error '%v'", err)
+		}
+
+		_verifyequal(t, errObj.Code, MethodNotFoundCode)
+
+		calls := make(chan string, 1)
+
+		// notifications handler can make calls on the same client
+		c.SetNotificationHandler(func(method string, params json.RawMessage) {
+			if string(params) != "3" {
+				return
+			}
+
+			result, err := c.Call("sum", json.RawMessage(`[1, 2]`))
+			if err != nil {
+				t.Error(err)
+			}
+
+			calls <- string(result)
+		})
+
+		if _, err = c.Call("sum", json.RawMessage(`[1, 1, 1]`)); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case result := <-calls:
+			_verifyequal(t, result, "3")
+		case <-time.After(5 * time.Second):
+			t.Fatal("notifications handler is blocked")
+		}
+
+		if err = c.Notify("sum", json.RawMessage(`[1]`)); err != nil {
+			t.Fatal(err)
+		}
+
+		// subprocess exits on EOF
+		if err = c.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = c.Call("sum", nil)
+		_verifyequal(t, err != nil, true)
+	}
+}
+
+// countingListener counts accepted connections.
+type countingListener struct {
+	net.Listener
+
+	mu    sync.Mutex
+	count int
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err == nil {
+		l.mu.Lock()
+		l.count++
+		l.mu.Unlock()
+	}
+
+	return c, err
+}
+
+func TestHTTPVersions(t *testing.T) {
+	testService := Create("")
+
+	_verifyequal(t, testService.GetHTTPVersions(), []string{"HTTP/1.1"})
+	_verifyequal(t, testService.SetHTTPVersions("3") != nil, true)
+	_verifyequal(t, testService.SetHTTPVersions() != nil, true)
+
+	// HTTP/2 negotiation over TLS is disabled unless HTTP/2 is accepted
+	srv, err := testService.newHTTPServer(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, srv.TLSNextProto != nil && len(srv.TLSNextProto) == 0, true)
+
+	if err = testService.SetHTTPVersions("1.0", "1.1", "2"); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, testService.GetHTTPVersions(), []string{"HTTP/1.0", "HTTP/1.1", "HTTP/2.0"})
+
+	// returned versions are a copy
+	testService.GetHTTPVersions()[0] = "HTTP/0.9"
+	_verifyequal(t, testService.GetHTTPVersions()[0], "HTTP/1.0")
+
+	srv, err = testService.newHTTPServer(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, srv.TLSNextProto == nil, true)
+
+	// accepted versions are validated
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Proto = "HTTP/1.0"
+
+	respObj := new(ResponseObject)
+	_verifyequal(t, respObj.ValidateHTTPProtocolVersions(req, testService.GetHTTPVersions()), true)
+
+	req.Proto = "HTTP/3.0"
+	_verifyequal(t, respObj.ValidateHTTPProtocolVersions(req, testService.GetHTTPVersions()), false)
+	_verifyequal(t, respObj.Error.Data, "request protocol version must be one of HTTP/1.0, HTTP/1.1, HTTP/2.0")
+}
+
+func TestH2C(t *testing.T) {
+	sock := "/tmp/jrpc2_h2c.socket"
+	_ = os.Remove(sock)
+
+	testService := Create("")
+	testService.SetH2C(true)
+
+	if err := testService.SetHTTPVersions("1.1", "2"); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, testService.GetH2C(), true)
+
+	testService.Register("proto", func(params ParametersObject) (interface{}, *ErrorObject) {
+		return params.GetProto(), nil
+	})
+
+	ul, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := &countingListener{Listener: ul}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- testService.ServeListener(l)
+	}()
+
+	c := client.GetSocketConfig(sock, "/")
+	c.EnableHTTP2(true)
+
+	var wg sync.WaitGroup
+
+	// concurrent calls are multiplexed over single connection
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			result, err := c.Call("proto", nil)
+			if err != nil {
+				t.Error(err)
+
+				return
+			}
+
+			_verifyequal(t, string(result), `"HTTP/2.0"`)
+		}()
+	}
+
+	wg.Wait()
+
+	l.mu.Lock()
+	_verifyequal(t, l.count, 1)
+	l.mu.Unlock()
+
+	// HTTP/1.1 clients are still served
+	c.EnableHTTP2(false)
+
+	result, err := c.Call("proto", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(result), `"HTTP/1.1"`)
+
+	if err = testService.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, <-errCh, nil)
+}
+
+func TestConnCloseBlockedWrite(t *testing.T) {
+	testService := Create("")
+
+	testService.Register("ping", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "pong", nil
+	})
+
+	pr, pw := io.Pipe()
+	out, peer := net.Pipe()
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- testService.ServeIO(pr, out, NewlineFraming)
+	}()
+
+	// response write blocks, nobody reads peer side
+	if _, err := pw.Write([]byte(`{"jsonrpc": "2.0", "method": "ping", "id": 1}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	done := make(chan error, 1)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		done <- testService.Shutdown(ctx)
+	}()
+
+	// pending write is never drained, connection is closed when shutdown context is done
+	select {
+	case err := <-done:
+		_verifyequal(t, err, context.DeadlineExceeded)
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown blocked by pending write")
+	}
+
+	// closing peer side unblocks pending write
+	_ = peer.Close()
+
+	_verifyequal(t, <-errCh, nil)
+}
+
+func TestConnConcurrency(t *testing.T) {
+	var (
+		mu           sync.Mutex
+		active, peak int
+	)
+
+	testService := Create("")
+	_verifyequal(t, testService.GetConnConcurrency(), DefaultConnConcurrency)
+
+	testService.SetConnConcurrency(2)
+	_verifyequal(t, testService.GetConnConcurrency(), 2)
+
+	release := make(chan struct{})
+
+	testService.Register("block", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		mu.Lock()
+		active++
+		if active > peak {
+			peak = active
+		}
+		mu.Unlock()
+
+		<-release
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+
+		return true, nil
+	})
+
+	conn, peer := net.Pipe()
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- testService.ServeIO(conn, conn, NewlineFraming)
+	}()
+
+	// pipelined requests, read loop waits while limit is reached
+	go func() {
+		for i := 1; i <= 5; i++ {
+			if _, err := fmt.Fprintf(peer, `{"jsonrpc": "2.0", "method": "block", "id": %d}`+"\n", i); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	_verifyequal(t, active, 2)
+	mu.Unlock()
+
+	close(release)
+
+	scanner := bufio.NewScanner(peer)
+
+	for i := 0; i < 5; i++ {
+		if !scanner.Scan() {
+			t.Fatal(scanner.Err())
+		}
+	}
+
+	mu.Lock()
+	_verifyequal(t, peak, 2)
+	mu.Unlock()
+
+	_ = peer.Close()
+
+	<-errCh
+}
+
+func TestStreamClientUnmatchedError(t *testing.T) {
+	conn, peer := net.Pipe()
+
+	c := client.NewStreamClient(conn, client.NewlineFraming)
+	defer c.Close()
+
+	// server fails to parse request and answers with null ID
+	go func() {
+		if _, err := bufio.NewReader(peer).ReadBytes('\n'); err != nil {
+			t.Error(err)
+
+			return
+		}
+
+		_, _ = peer.Write([]byte(`{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}` + "\n"))
+	}()
+
+	start := time.Now()
+
+	_, err := c.Call("update", nil)
+
+	errObj, ok := err.(*client.ErrorObject)
+	if !ok {
+		t.Fatalf("unexpected error '%v'", err)
+	}
+
+	_verifyequal(t, errObj.Code, ParseErrorCode)
+	_verifyequal(t, time.Since(start) < time.Second, true)
+}
@@ -0,0 +1,91 @@
+package jrpc2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicData represents error data of recovered method panic, used in debug mode.
+type PanicData struct {
+	// Panic contains recovered panic value
+	Panic string `json:"panic"`
+	// Stack contains stack trace of panicked method
+	Stack string `json:"stack"`
+}
+
+// SetPanicHandler defines function that is called with method name, raw JSON params and stack trace
+// when registered method panics.
+func (s *Service) SetPanicHandler(f func(name string, params json.RawMessage, stack []byte)) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.panicHandler = f
+}
+
+// SetDebugMode sets debug mode flag in service object, debug mode exposes panic stack trace in error data.
+func (s *Service) SetDebugMode(flag bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.debug = flag
+}
+
+// GetDebugMode gets debug mode flag from service object.
+func (s *Service) GetDebugMode() bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.debug
+}
+
+// handleRecover calls handler, recovers handler panic and reports it as internal error.
+func (s *Service) handleRecover(h Handler, data ParametersObject) (result interface{}, errObj *ErrorObject) {
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+
+		// aborted HTTP handler must reach HTTP library, only on goroutine owned by HTTP server
+		if rec == http.ErrAbortHandler && abortHandlerFlagFromContext(data.GetContext()) { // nolint: goerr113
+			panic(rec)
+		}
+
+		result, errObj = nil, s.recoverPanic(data, rec)
+	}()
+
+	return h(data)
+}
+
+// recoverPanic reports recovered method panic to panic handler, returns internal error object.
+func (s *Service) recoverPanic(data ParametersObject, rec interface{}) *ErrorObject {
+	stack := debug.Stack()
+
+	s.Lock()
+	handler := s.panicHandler
+	debugMode := s.debug
+	s.Unlock()
+
+	// report panic
+	if handler != nil {
+		handler(data.GetMethodName(), data.GetRawJSONParams(), stack)
+	}
+
+	errObj := &ErrorObject{
+		Code:    InternalErrorCode,
+		Message: InternalErrorMessage,
+		Data:    "method panicked",
+	}
+
+	// expose stack trace
+	if debugMode {
+		errObj.Data = PanicData{
+			Panic: fmt.Sprint(rec),
+			Stack: string(stack),
+		}
+	}
+
+	return errObj
+}
@@ -1,6 +1,7 @@
 package jrpc2
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 )
@@ -14,6 +15,8 @@ type ParametersObject struct {
 
 	r *http.Request // contains pointer to HTTP request object
 
+	ctx context.Context // contains method context, HTTP request context is used when not defined
+
 	params json.RawMessage // contains raw JSON params of invoked method
 }
 
@@ -37,6 +40,31 @@ func (p ParametersObject) GetMethodName() string {
 	return p.method
 }
 
+// GetContext returns method context, it is derived from HTTP request context when request is defined.
+func (p ParametersObject) GetContext() context.Context {
+	if p.ctx != nil {
+		return p.ctx
+	}
+
+	if p.r == nil {
+		return context.Background()
+	}
+
+	return p.r.Context()
+}
+
+// withContext returns copy of parameters object with provided method context,
+// HTTP request carries the same context.
+func (p ParametersObject) withContext(ctx context.Context) ParametersObject {
+	p.ctx = ctx
+
+	if p.r != nil {
+		p.r = p.r.WithContext(ctx)
+	}
+
+	return p
+}
+
 // GetRemoteAddress returns remote address of request source.
 func (p ParametersObject) GetRemoteAddress() string {
 	if behindReverseProxyFlagFromContext(p.r.Context()) {
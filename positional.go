@@ -4,18 +4,26 @@ import (
 	"encoding/json"
 )
 
+// unmarshalParams decodes raw JSON params to v, returns invalid params error object on failure.
+func unmarshalParams(params json.RawMessage, v interface{}) *ErrorObject {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+			Data:    err.Error(),
+		}
+	}
+
+	return nil
+}
+
 // GetPositionalFloat64Params parses positional param member of JSON-RPC 2.0 request
 // that is know to contain float64 array.
 func GetPositionalFloat64Params(data ParametersObject) ([]float64, *ErrorObject) {
 	params := make([]float64, 0)
 
-	err := json.Unmarshal(data.GetRawJSONParams(), &params)
-	if err != nil {
-		return nil, &ErrorObject{
-			Code:    InvalidParamsCode,
-			Message: InvalidParamsMessage,
-			Data:    err.Error(),
-		}
+	if errObj := unmarshalParams(data.GetRawJSONParams(), &params); errObj != nil {
+		return nil, errObj
 	}
 
 	return params, nil
@@ -26,13 +34,8 @@ func GetPositionalFloat64Params(data ParametersObject) ([]float64, *ErrorObject)
 func GetPositionalInt64Params(data ParametersObject) ([]int64, *ErrorObject) {
 	params := make([]int64, 0)
 
-	err := json.Unmarshal(data.GetRawJSONParams(), &params)
-	if err != nil {
-		return nil, &ErrorObject{
-			Code:    InvalidParamsCode,
-			Message: InvalidParamsMessage,
-			Data:    err.Error(),
-		}
+	if errObj := unmarshalParams(data.GetRawJSONParams(), &params); errObj != nil {
+		return nil, errObj
 	}
 
 	return params, nil
@@ -43,13 +46,8 @@ func GetPositionalInt64Params(data ParametersObject) ([]int64, *ErrorObject) {
 func GetPositionalIntParams(data ParametersObject) ([]int, *ErrorObject) {
 	params := make([]int, 0)
 
-	err := json.Unmarshal(data.GetRawJSONParams(), &params)
-	if err != nil {
-		return nil, &ErrorObject{
-			Code:    InvalidParamsCode,
-			Message: InvalidParamsMessage,
-			Data:    err.Error(),
-		}
+	if errObj := unmarshalParams(data.GetRawJSONParams(), &params); errObj != nil {
+		return nil, errObj
 	}
 
 	return params, nil
@@ -60,13 +58,8 @@ func GetPositionalIntParams(data ParametersObject) ([]int, *ErrorObject) {
 func GetPositionalUint64Params(data ParametersObject) ([]uint64, *ErrorObject) {
 	params := make([]uint64, 0)
 
-	err := json.Unmarshal(data.GetRawJSONParams(), &params)
-	if err != nil {
-		return nil, &ErrorObject{
-			Code:    InvalidParamsCode,
-			Message: InvalidParamsMessage,
-			Data:    err.Error(),
-		}
+	if errObj := unmarshalParams(data.GetRawJSONParams(), &params); errObj != nil {
+		return nil, errObj
 	}
 
 	return params, nil
@@ -77,13 +70,8 @@ func GetPositionalUint64Params(data ParametersObject) ([]uint64, *ErrorObject) {
 func GetPositionalUintParams(data ParametersObject) ([]uint, *ErrorObject) {
 	params := make([]uint, 0)
 
-	err := json.Unmarshal(data.GetRawJSONParams(), &params)
-	if err != nil {
-		return nil, &ErrorObject{
-			Code:    InvalidParamsCode,
-			Message: InvalidParamsMessage,
-			Data:    err.Error(),
-		}
+	if errObj := unmarshalParams(data.GetRawJSONParams(), &params); errObj != nil {
+		return nil, errObj
 	}
 
 	return params, nil
@@ -94,13 +82,8 @@ func GetPositionalUintParams(data ParametersObject) ([]uint, *ErrorObject) {
 func GetPositionalStringParams(data ParametersObject) ([]string, *ErrorObject) {
 	params := make([]string, 0)
 
-	err := json.Unmarshal(data.GetRawJSONParams(), &params)
-	if err != nil {
-		return nil, &ErrorObject{
-			Code:    InvalidParamsCode,
-			Message: InvalidParamsMessage,
-			Data:    err.Error(),
-		}
+	if errObj := unmarshalParams(data.GetRawJSONParams(), &params); errObj != nil {
+		return nil, errObj
 	}
 
 	return params, nil
@@ -0,0 +1,110 @@
+package jrpc2
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// SetHTTPVersions sets HTTP protocol versions accepted by service, one or more of "1.0", "1.1" and "2".
+// Requests with other protocol versions are rejected with 501 (not implemented).
+// HTTP/2 is negotiated over TLS only when it is accepted, see SetH2C for HTTP/2 without TLS.
+func (s *Service) SetHTTPVersions(versions ...string) error {
+	protos := make([]string, 0, len(versions))
+
+	for _, el := range versions {
+		switch el {
+		case "1.0":
+			protos = append(protos, "HTTP/1.0")
+		case "1.1":
+			protos = append(protos, "HTTP/1.1")
+		case "2":
+			protos = append(protos, "HTTP/2.0")
+		default:
+			return fmt.Errorf("unsupported HTTP protocol version '%s'", el)
+		}
+	}
+
+	if len(protos) == 0 {
+		return fmt.Errorf("at least one HTTP protocol version must be accepted")
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.httpVersions = protos
+
+	return nil
+}
+
+// GetHTTPVersions gets HTTP protocol versions accepted by service, (e.g. "HTTP/1.1").
+// Endpoint accepts HTTP protocol versions of parent service unless they are set on endpoint.
+func (s *Service) GetHTTPVersions() []string {
+	s.Lock()
+	versions, parent := s.httpVersions, s.parent
+	s.Unlock()
+
+	if versions == nil && parent != nil {
+		return parent.GetHTTPVersions()
+	}
+
+	if versions == nil {
+		return []string{DefaultHTTPVersion}
+	}
+
+	// prepare slice for results
+	out := make([]string, len(versions))
+
+	// copy versions to new slice
+	copy(out, versions)
+
+	return out
+}
+
+// acceptsHTTP2 checks that HTTP/2 is accepted by service, must be called with service lock held.
+func (s *Service) acceptsHTTP2() bool {
+	for _, el := range s.httpVersions {
+		if el == "HTTP/2.0" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetH2C enables HTTP/2 without TLS (h2c) for unix socket and plain TCP modes,
+// HTTP/2 must be accepted by service, see SetHTTPVersions.
+func (s *Service) SetH2C(flag bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.h2c = flag
+}
+
+// GetH2C returns true when HTTP/2 without TLS (h2c) is enabled.
+func (s *Service) GetH2C() bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.h2c
+}
+
+// configureHTTP2 configures HTTP/2 support of HTTP server, must be called with service lock held.
+func (s *Service) configureHTTP2(srv *http.Server, secure bool) {
+	// disable HTTP/2 negotiation over TLS, clients fall back to HTTP/1.1
+	if !s.acceptsHTTP2() {
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+
+		return
+	}
+
+	// HTTP/2 without TLS
+	if !secure && s.h2c {
+		srv.Handler = h2c.NewHandler(srv.Handler, &http2.Server{
+			IdleTimeout: s.idleTimeout,
+		})
+	}
+}
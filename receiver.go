@@ -0,0 +1,118 @@
+package jrpc2
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NamingScheme defines how RegisterService builds method names from namespace and Go method name.
+type NamingScheme int
+
+const (
+	// DotNaming builds method names as "Namespace.Method".
+	DotNaming NamingScheme = iota
+	// UnderscoreNaming builds method names as "namespace_method".
+	UnderscoreNaming
+)
+
+// lowerFirst lowercases first letter of provided string.
+func lowerFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+
+	return string(unicode.ToLower(r)) + s[n:]
+}
+
+// methodName builds method name from namespace and Go method name using naming scheme.
+func (scheme NamingScheme) methodName(namespace, name string) string {
+	if scheme == UnderscoreNaming {
+		return fmt.Sprintf("%s_%s", lowerFirst(namespace), lowerFirst(name))
+	}
+
+	return fmt.Sprintf("%s.%s", namespace, name)
+}
+
+// isExportedOrBuiltinType reports whether type (or type it points to) is exported or builtin.
+func isExportedOrBuiltinType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return token.IsExported(t.Name()) || t.PkgPath() == ""
+}
+
+// receiverMethod builds method from bound Go method, returns false when method signature is not supported.
+func receiverMethod(mv reflect.Value) (method, bool) {
+	switch f := mv.Interface().(type) {
+	case func(ParametersObject) (interface{}, *ErrorObject):
+		return method{
+			Method: f,
+		}, true
+	case func(context.Context, ParametersObject) (interface{}, *ErrorObject):
+		return method{
+			ContextMethod: f,
+		}, true
+	}
+
+	m, err := typedMethod(mv)
+	if err != nil {
+		return method{}, false
+	}
+
+	// Args and Reply of unexported types are internal to receiver package
+	if !isExportedOrBuiltinType(m.argType) || !isExportedOrBuiltinType(m.replyType) {
+		return method{}, false
+	}
+
+	return m, true
+}
+
+// RegisterService registers all exported methods of receiver that have signature accepted by
+// Register, RegisterContext or RegisterTyped, other exported methods are skipped.
+// Typed methods are registered only when Args and Reply types are exported or builtin.
+//
+// Warning: every suitable exported method is callable remotely, including incidental ones,
+// e.g. Read([]byte) (int, error) or Write([]byte) (int, error) of receiver implementing io interfaces.
+// Use receiver type that exposes only intended methods or register methods one by one.
+// Empty namespace defaults to receiver type name, method names are built using naming scheme.
+func (s *Service) RegisterService(receiver interface{}, namespace string, scheme NamingScheme) error {
+	rv := reflect.ValueOf(receiver)
+
+	if !rv.IsValid() {
+		return fmt.Errorf("receiver must not be nil")
+	}
+
+	// default namespace
+	if namespace == "" {
+		namespace = reflect.Indirect(rv).Type().Name()
+	}
+
+	if namespace == "" {
+		return fmt.Errorf("namespace must be defined for unnamed receiver type '%s'", rv.Type())
+	}
+
+	methods := make(map[string]method)
+
+	// collect methods with supported signatures
+	for i := 0; i < rv.NumMethod(); i++ {
+		m, ok := receiverMethod(rv.Method(i))
+		if !ok {
+			continue
+		}
+
+		methods[scheme.methodName(namespace, rv.Type().Method(i).Name)] = m
+	}
+
+	if len(methods) == 0 {
+		return fmt.Errorf("receiver type '%s' has no exported methods of suitable type", rv.Type())
+	}
+
+	for name, m := range methods {
+		s.register(name, m)
+	}
+
+	return nil
+}
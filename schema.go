@@ -0,0 +1,146 @@
+package jrpc2
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// nolint: gochecknoglobals
+var (
+	typeOfTime       = reflect.TypeOf(time.Time{})
+	typeOfRawMessage = reflect.TypeOf(json.RawMessage{})
+)
+
+// jsonFieldName returns JSON object key for struct field, empty string for ignored fields.
+func jsonFieldName(field reflect.StructField) (name string, omitempty bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	parts := strings.Split(tag, ",")
+
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	for _, el := range parts[1:] {
+		if el == "omitempty" {
+			omitempty = true
+		}
+	}
+
+	return name, omitempty
+}
+
+// jsonSchema generates JSON Schema for provided Go type.
+func jsonSchema(t reflect.Type) map[string]interface{} {
+	return jsonSchemaWithSeen(t, make(map[reflect.Type]bool))
+}
+
+// nolint: gocyclo
+func jsonSchemaWithSeen(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case typeOfTime:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case typeOfRawMessage:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		// byte slices are encoded as base64 strings
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+
+		return map[string]interface{}{
+			"type":  "array",
+			"items": jsonSchemaWithSeen(t.Elem(), seen),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": jsonSchemaWithSeen(t.Elem(), seen),
+		}
+	case reflect.Struct:
+		// recursive types are not expanded
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := make(map[string]interface{})
+		required := make([]string, 0)
+
+		jsonSchemaStructFields(t, seen, properties, &required)
+
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+
+		return schema
+	default: // interfaces and other types accept any value
+		return map[string]interface{}{}
+	}
+}
+
+// jsonSchemaStructFields fills JSON Schema properties from struct fields, embedded structs are flattened.
+func jsonSchemaStructFields(t reflect.Type, seen map[reflect.Type]bool, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		// flatten embedded structs without JSON name
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				jsonSchemaStructFields(ft, seen, properties, required)
+
+				continue
+			}
+		}
+
+		// skip unexported fields
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name, omitempty := jsonFieldName(field)
+		if name == "" {
+			continue
+		}
+
+		properties[name] = jsonSchemaWithSeen(field.Type, seen)
+
+		if !omitempty && field.Type.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+}
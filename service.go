@@ -1,10 +1,15 @@
 package jrpc2
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Service represents a JSON-RPC 2.0 capable HTTP server.
@@ -18,6 +23,9 @@ type Service struct {
 
 	route string // path to the JSON-RPC 2.0 HTTP endpoint
 
+	endpoints map[string]*Service // additional JSON-RPC 2.0 HTTP endpoints served by the same server, mapped by route
+	parent    *Service            // service that serves this endpoint, nil for top-level service
+
 	socket  *string // unix socket path for the server
 	address *string // address (IP:PORT) for TCP socket to bind listener to
 
@@ -27,12 +35,59 @@ type Service struct {
 
 	behindReverseProxy bool // flags that changes behavior of some internal methods (X-Real-IP, X-Client-IP)
 
+	batchConcurrency int             // maximum number of concurrently invoked batch elements, sequential when below 2
+	batchLimit       int             // maximum number of elements in batch request, unlimited when 0
+	sequential       map[string]bool // methods that must keep their order inside batch request
+
+	methodTimeout time.Duration // maximum execution time of method, cancels method context, disabled when 0
+
+	info OpenRPCInfo // service metadata for service discovery document
+
+	middleware          []Middleware            // chain around method invocation
+	methodMiddleware    map[string][]Middleware // per-method chains
+	namespaceMiddleware []namespaceMiddleware   // per-namespace chains
+
+	panicHandler func(name string, params json.RawMessage, stack []byte) // receives recovered method panics
+	debug        bool                                                    // exposes panic stack trace in error data
+
+	maxRequestSize int64            // maximum request body size in bytes, unlimited when 0
+	routeLimits    map[string]int64 // maximum request body size overrides, mapped by request URL path
+
+	readHeaderTimeout time.Duration // HTTP server maximum duration for reading request headers
+	readTimeout       time.Duration // HTTP server maximum duration for reading entire request
+	writeTimeout      time.Duration // HTTP server maximum duration before timing out writes of response
+	idleTimeout       time.Duration // HTTP server maximum duration to wait for the next request with keep-alive
+	maxHeaderBytes    int           // HTTP server maximum number of bytes in request headers
+
+	httpVersions []string // accepted HTTP protocol versions, HTTP/1.1 only when nil
+	h2c          bool     // enables HTTP/2 without TLS for unix socket and plain TCP modes
+
+	server          *http.Server  // HTTP server owned by service, defined when service is started
+	shutdown        bool          // flags that service was shut down
+	shutdownTimeout time.Duration // maximum time to wait for active requests on context-driven shutdown
+
+	conns           map[*Conn]struct{}        // persistent client connections, closed on shutdown
+	connConcurrency int                       // maximum number of concurrently processed messages per connection
+	listeners       map[net.Listener]struct{} // stream transport listeners, closed on shutdown
+
+	webSocket     bool                       // enables WebSocket transport on service route
+	wsCheckOrigin func(r *http.Request) bool // validates WebSocket handshake Origin header
+
 	methods map[string]method        // mapping of registered methods
+	aliases map[string]*alias        // mapping of method aliases to registered methods
 	headers map[string]string        // custom response headers
 	auth    map[string]authorization // contains mapping of allowed remote network to HTTP Authorization header
 
 	req  func(r *http.Request, data []byte) error // defines request function hook, runs just after request body is read
 	resp func(r *http.Request, data []byte) error // defines response function hook, runs just before response is written
+
+	reqHooks  []Hook // ordered request hooks, run after request function hook
+	respHooks []Hook // ordered response hooks, run after response function hook
+
+	hookErrorResponse bool // hook failures emit JSON-RPC error response
+	hookErrorCode     int  // error code of JSON-RPC error response for hook failures
+
+	deprecationErrorData bool // wraps error data of deprecated alias calls
 }
 
 // Create defines a new service instance over Unix Socket.
@@ -51,6 +106,11 @@ func Create(socket string) *Service {
 
 		proxy: false,
 
+		info: defaultOpenRPCInfo(),
+
+		shutdownTimeout: DefaultShutdownTimeout,
+		hookErrorCode:   HookErrorCode,
+
 		req: func(r *http.Request, data []byte) error {
 			return nil
 		},
@@ -60,6 +120,12 @@ func Create(socket string) *Service {
 	}
 }
 
+// CreateOverTCP defines a new service instance over TCP without TLS (plain HTTP),
+// for use behind TLS-terminating proxy.
+func CreateOverTCP(address, route string) *Service {
+	return CreateOverTCPWithTLS(address, route, "", "")
+}
+
 /*
 openssl req -newkey rsa:2048 -nodes -keyout domain.key -x509 -days 365 -out domain.crt \
   -subj "/C=UA/ST=Kyiv/L=Kyiv/O=Office/OU=Org/CN=localhost"
@@ -83,6 +149,11 @@ func CreateOverTCPWithTLS(address, route, key, cert string) *Service {
 
 		proxy: false,
 
+		info: defaultOpenRPCInfo(),
+
+		shutdownTimeout: DefaultShutdownTimeout,
+		hookErrorCode:   HookErrorCode,
+
 		req: func(r *http.Request, data []byte) error {
 			return nil
 		},
@@ -108,6 +179,11 @@ func CreateProxy(socket string) *Service {
 
 		proxy: true,
 
+		info: defaultOpenRPCInfo(),
+
+		shutdownTimeout: DefaultShutdownTimeout,
+		hookErrorCode:   HookErrorCode,
+
 		req: func(r *http.Request, data []byte) error {
 			return nil
 		},
@@ -117,6 +193,11 @@ func CreateProxy(socket string) *Service {
 	}
 }
 
+// CreateProxyOverTCP defines a new proxy service over TCP without TLS (plain HTTP).
+func CreateProxyOverTCP(address, route string) *Service {
+	return CreateProxyOverTCPWithTLS(address, route, "", "")
+}
+
 // CreateProxyOverTCPWithTLS defines a new proxy service over TCP with TLS (HTTPS).
 func CreateProxyOverTCPWithTLS(address, route, key, cert string) *Service {
 	return &Service{
@@ -135,6 +216,11 @@ func CreateProxyOverTCPWithTLS(address, route, key, cert string) *Service {
 
 		proxy: true,
 
+		info: defaultOpenRPCInfo(),
+
+		shutdownTimeout: DefaultShutdownTimeout,
+		hookErrorCode:   HookErrorCode,
+
 		req: func(r *http.Request, data []byte) error {
 			return nil
 		},
@@ -154,9 +240,10 @@ func (s *Service) Unlock() {
 	s.mu.Unlock()
 }
 
-// SetSocket sets custom unix socket in service object.
+// SetSocket sets custom unix socket in service object, network address is unset.
 func (s *Service) SetSocket(socket string) {
 	s.socket = &socket
+	s.address = nil
 }
 
 // GetSocket gets custom unix socket from service object.
@@ -168,9 +255,10 @@ func (s *Service) GetSocket() string {
 	return *s.socket
 }
 
-// SetAddress sets custom network address in service object.
+// SetAddress sets custom network address in service object, unix socket is unset.
 func (s *Service) SetAddress(address string) {
 	s.address = &address
+	s.socket = nil
 }
 
 // GetAddress gets custom network address from service object.
@@ -194,6 +282,11 @@ func (s *Service) GetSocketPermissions() uint32 {
 
 // SetRoute sets custom route in service object.
 func (s *Service) SetRoute(route string) {
+	s.route = normalizeRoute(route)
+}
+
+// normalizeRoute trims route, defaults empty route to "/" and adds leading slash.
+func normalizeRoute(route string) string {
 	route = strings.TrimSpace(route)
 
 	if len(route) == 0 {
@@ -204,7 +297,7 @@ func (s *Service) SetRoute(route string) {
 		route = fmt.Sprintf("/%s", route)
 	}
 
-	s.route = route
+	return route
 }
 
 // GetRoute gets custom route from service object.
@@ -266,19 +359,409 @@ func (s *Service) GetHeaders() map[string]string {
 	return out
 }
 
-// Register maps the provided method name to the given function for later method calls.
-func (s *Service) Register(name string, f func(ParametersObject) (interface{}, *ErrorObject)) {
+// SetBatchConcurrency sets maximum number of concurrently invoked batch elements in service object.
+func (s *Service) SetBatchConcurrency(n int) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.batchConcurrency = n
+}
+
+// GetBatchConcurrency gets maximum number of concurrently invoked batch elements from service object.
+func (s *Service) GetBatchConcurrency() int {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.batchConcurrency
+}
+
+// SetConnConcurrency sets maximum number of concurrently processed messages per persistent connection
+// (WebSocket, stream) in service object, reading of next message waits while limit is reached.
+// DefaultConnConcurrency is used when n is below 1.
+func (s *Service) SetConnConcurrency(n int) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.connConcurrency = n
+}
+
+// GetConnConcurrency gets maximum number of concurrently processed messages per persistent connection from service object.
+func (s *Service) GetConnConcurrency() int {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.connConcurrency < 1 {
+		return DefaultConnConcurrency
+	}
+
+	return s.connConcurrency
+}
+
+// SetBatchSizeLimit sets maximum number of elements in batch request in service object, 0 disables limit.
+func (s *Service) SetBatchSizeLimit(n int) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.batchLimit = n
+}
+
+// GetBatchSizeLimit gets maximum number of elements in batch request from service object.
+func (s *Service) GetBatchSizeLimit() int {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.batchLimit
+}
+
+// SetSequentialMethods sets methods that are invoked sequentially, in request order, inside concurrent batch.
+// Calls through aliases (and unversioned names) of sequential method are sequential too.
+func (s *Service) SetSequentialMethods(names []string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.sequential = make(map[string]bool)
+
+	for _, name := range names {
+		s.sequential[name] = true
+	}
+}
+
+// GetSequentialMethods gets methods that are invoked sequentially inside concurrent batch from service object.
+func (s *Service) GetSequentialMethods() []string {
+	s.Lock()
+	defer s.Unlock()
+
+	// prepare slice for results
+	out := make([]string, 0, len(s.sequential))
+
+	// copy method names to new slice
+	for name := range s.sequential {
+		out = append(out, name)
+	}
+
+	sort.Strings(out)
+
+	return out
+}
+
+// isSequentialMethod checks that method must keep its order inside batch request.
+func (s *Service) isSequentialMethod(name string) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.sequential[name]
+}
+
+// SetMethodTimeout sets maximum method execution time in service object, 0 disables timeout.
+func (s *Service) SetMethodTimeout(timeout time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.methodTimeout = timeout
+}
+
+// GetMethodTimeout gets maximum method execution time from service object.
+func (s *Service) GetMethodTimeout() time.Duration {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.methodTimeout
+}
+
+// register maps the provided method name to the given method, proxy service does not accept named methods.
+// Re-registered method keeps its execution timeout, params JSON Schema and OpenRPC descriptor,
+// unless they are defined by new method.
+func (s *Service) register(name string, m method) {
+	s.Lock()
+	defer s.Unlock()
+
 	if s.proxy {
 		s.methods = nil
-	} else {
-		s.methods[name] = method{
-			Method: f,
+
+		return
+	}
+
+	if old, ok := s.methods[name]; ok {
+		if m.timeout == 0 {
+			m.timeout = old.timeout
 		}
+
+		if m.validator == nil {
+			m.validator = old.validator
+		}
+
+		if m.descriptor == nil {
+			m.descriptor = old.descriptor
+		}
+	}
+
+	s.methods[name] = m
+}
+
+// updateMethod applies update to registered method, returns error for unknown method.
+func (s *Service) updateMethod(name string, update func(m *method)) error {
+	s.Lock()
+	defer s.Unlock()
+
+	m, ok := s.methods[name]
+	if !ok {
+		return fmt.Errorf("method '%s' is not registered", name)
+	}
+
+	update(&m)
+	s.methods[name] = m
+
+	return nil
+}
+
+// getMethod returns registered method by name.
+func (s *Service) getMethod(name string) (method, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	m, ok := s.methods[name]
+
+	return m, ok
+}
+
+// Unregister removes named method, returns false when method is not registered.
+// It is safe to call while service is serving requests, in-flight calls complete normally.
+func (s *Service) Unregister(name string) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.methods[name]; !ok {
+		return false
+	}
+
+	delete(s.methods, name)
+
+	return true
+}
+
+// HasMethod checks that named method is registered.
+func (s *Service) HasMethod(name string) bool {
+	_, ok := s.getMethod(name)
+
+	return ok
+}
+
+// GetMethods returns sorted list of registered method names.
+func (s *Service) GetMethods() []string {
+	s.Lock()
+	defer s.Unlock()
+
+	names := make([]string, 0, len(s.methods))
+
+	for name := range s.methods {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// SetShutdownTimeout sets maximum time to wait for active requests on context-driven shutdown in service object.
+func (s *Service) SetShutdownTimeout(timeout time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.shutdownTimeout = timeout
+}
+
+// GetShutdownTimeout gets maximum time to wait for active requests on context-driven shutdown from service object.
+func (s *Service) GetShutdownTimeout() time.Duration {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.shutdownTimeout
+}
+
+// SetMethodTimeoutFor sets maximum execution time of registered method, overrides service method timeout.
+func (s *Service) SetMethodTimeoutFor(name string, timeout time.Duration) error {
+	return s.updateMethod(name, func(m *method) {
+		m.timeout = timeout
+	})
+}
+
+// SetMaxRequestSize sets maximum request body size in bytes in service object, 0 disables limit.
+func (s *Service) SetMaxRequestSize(n int64) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.maxRequestSize = n
+}
+
+// GetMaxRequestSize gets maximum request body size in bytes from service object.
+func (s *Service) GetMaxRequestSize() int64 {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.maxRequestSize
+}
+
+// SetRouteMaxRequestSize sets maximum request body size in bytes for requests with provided URL path,
+// overrides service maximum request size, 0 disables limit for this route.
+func (s *Service) SetRouteMaxRequestSize(route string, n int64) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.routeLimits == nil {
+		s.routeLimits = make(map[string]int64)
 	}
+
+	s.routeLimits[route] = n
+}
+
+// GetMaxRequestSizeForRoute gets maximum request body size in bytes for requests with provided URL path.
+func (s *Service) GetMaxRequestSizeForRoute(route string) int64 {
+	s.Lock()
+	defer s.Unlock()
+
+	if n, ok := s.routeLimits[route]; ok {
+		return n
+	}
+
+	return s.maxRequestSize
+}
+
+// SetReadHeaderTimeout sets HTTP server maximum duration for reading request headers in service object.
+func (s *Service) SetReadHeaderTimeout(timeout time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.readHeaderTimeout = timeout
+}
+
+// GetReadHeaderTimeout gets HTTP server maximum duration for reading request headers from service object.
+func (s *Service) GetReadHeaderTimeout() time.Duration {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.readHeaderTimeout
+}
+
+// SetReadTimeout sets HTTP server maximum duration for reading entire request in service object.
+func (s *Service) SetReadTimeout(timeout time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.readTimeout = timeout
+}
+
+// GetReadTimeout gets HTTP server maximum duration for reading entire request from service object.
+func (s *Service) GetReadTimeout() time.Duration {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.readTimeout
+}
+
+// SetWriteTimeout sets HTTP server maximum duration before timing out writes of response in service object.
+func (s *Service) SetWriteTimeout(timeout time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.writeTimeout = timeout
+}
+
+// GetWriteTimeout gets HTTP server maximum duration before timing out writes of response from service object.
+func (s *Service) GetWriteTimeout() time.Duration {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.writeTimeout
+}
+
+// SetIdleTimeout sets HTTP server maximum duration to wait for the next request with keep-alive in service object.
+func (s *Service) SetIdleTimeout(timeout time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.idleTimeout = timeout
+}
+
+// GetIdleTimeout gets HTTP server maximum duration to wait for the next request with keep-alive from service object.
+func (s *Service) GetIdleTimeout() time.Duration {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.idleTimeout
+}
+
+// SetMaxHeaderBytes sets HTTP server maximum number of bytes in request headers in service object.
+func (s *Service) SetMaxHeaderBytes(n int) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.maxHeaderBytes = n
+}
+
+// GetMaxHeaderBytes gets HTTP server maximum number of bytes in request headers from service object.
+func (s *Service) GetMaxHeaderBytes() int {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.maxHeaderBytes
+}
+
+// Register maps the provided method name to the given function for later method calls.
+func (s *Service) Register(name string, f func(ParametersObject) (interface{}, *ErrorObject)) {
+	s.register(name, method{
+		Method: f,
+	})
+}
+
+// RegisterContext maps the provided method name to the given context-aware function for later method calls.
+// Method context is derived from HTTP request context, it is cancelled when client goes away or method timeout fires.
+func (s *Service) RegisterContext(name string, f func(context.Context, ParametersObject) (interface{}, *ErrorObject)) {
+	s.register(name, method{
+		ContextMethod: f,
+	})
+}
+
+// RegisterWithSchema maps the provided method name to the given function with attached params JSON Schema,
+// method is registered only when schema is valid, see SetParamsSchema.
+func (s *Service) RegisterWithSchema(name string, schema []byte, f func(ParametersObject) (interface{}, *ErrorObject)) error {
+	validator, err := newParamsValidator(schema)
+	if err != nil {
+		return err
+	}
+
+	s.register(name, method{
+		Method:    f,
+		validator: validator,
+	})
+
+	return nil
+}
+
+// SetParamsSchema attaches JSON Schema to registered method, params are validated before method invocation.
+// Validation failure is reported as Invalid params with list of SchemaViolation as error data.
+// Supported JSON Schema is a subset of draft-07 without references ($ref), schema with unsupported
+// assertion keywords (e.g. "uniqueItems", "patternProperties", "if") is rejected.
+// Attached schema is kept when method is registered again under the same name.
+func (s *Service) SetParamsSchema(name string, schema []byte) error {
+	if !s.HasMethod(name) {
+		return fmt.Errorf("method '%s' is not registered", name)
+	}
+
+	validator, err := newParamsValidator(schema)
+	if err != nil {
+		return err
+	}
+
+	return s.updateMethod(name, func(m *method) {
+		m.validator = validator
+	})
 }
 
 // RegisterProxy maps the 'rpc.proxy' method name to the given function for later method calls.
 func (s *Service) RegisterProxy(f func(ParametersObject) (interface{}, *ErrorObject)) {
+	s.Lock()
+	defer s.Unlock()
+
 	if s.proxy {
 		s.methods = map[string]method{
 			"rpc.proxy": {
@@ -147,6 +147,11 @@ func Update(_ ParametersObject) (interface{}, *ErrorObject) {
 
 //revive:disable:deep-exit
 func TestMain(m *testing.M) {
+	// test binary is spawned as stdio service subprocess
+	if framing, ok := os.LookupEnv("JRPC2_STDIO_FRAMING"); ok {
+		stdioHelperProcess(framing)
+	}
+
 	// Seed random
 	rand.Seed(time.Now().UnixNano())
 
@@ -1202,37 +1207,82 @@ func TestBatchNotifications(t *testing.T) {
 		}
 	}()
 
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected HTTP status code to be '%d'", http.StatusNoContent)
+	}
+
+	err = json.NewDecoder(bufio.NewReader(resp.Body)).Decode(&result)
+	if err != io.EOF {
+		t.Fatal("expected empty response to batch of notifications")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var results []Result
+
+	req := `[
+			{"jsonrpc": "2.0", "method": "subtract", "params": {"X": #X, "Y": #Y}, "id": "1"},
+			{"jsonrpc": "2.0", "method": "update", "params": [7]},
+			{"jsonrpc": "2.0", "method": "subtract", "params": [#Y, #X], "id": 2},
+			{"foo": "boo"},
+			{"jsonrpc": "2.0", "method": "foo.get", "params": {"name": "myself"}, "id": "5"},
+			{"jsonrpc": "2.0", "method": 1, "id": "6"}
+		]`
+
+	resp, err := httpPost(
+		serverURL,
+		req,
+		serverSocket,
+		postHeaders,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		err = resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
 	if resp.StatusCode != http.StatusOK {
 		t.Fatalf("expected HTTP status code to be '%d'", http.StatusOK)
 	}
 
-	err = json.NewDecoder(bufio.NewReader(resp.Body)).Decode(&result)
+	err = json.NewDecoder(bufio.NewReader(resp.Body)).Decode(&results)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if result.Jsonrpc != JSONRPCVersion {
-		t.Fatalf("expected Jsonrpc to be '%s'", JSONRPCVersion)
+	if len(results) != 5 {
+		t.Fatalf("expected '5' responses, got '%d'", len(results))
 	}
 
-	if result.ID != nil {
-		t.Fatal("expected ID to be 'nil'")
+	for _, el := range results {
+		if el.Jsonrpc != JSONRPCVersion {
+			t.Fatalf("expected Jsonrpc to be '%s'", JSONRPCVersion)
+		}
 	}
 
-	if result.Result != nil {
-		t.Fatalf("expected Result to be 'nil'")
+	if results[0].ID != "1" || results[0].Error != nil || results[0].Result.(float64) != float64(x-y) {
+		t.Fatalf("expected first response to be '%f' with ID '1'", float64(x-y))
 	}
 
-	if result.Error == nil {
-		t.Fatal("expected Error to be not 'nil'")
+	if val, ok := results[1].ID.(float64); !ok || val != 2 || results[1].Result.(float64) != float64(y-x) {
+		t.Fatalf("expected second response to be '%f' with ID '2'", float64(y-x))
+	}
+
+	if results[2].ID != nil || results[2].Error == nil || results[2].Error.Code != InvalidRequestCode {
+		t.Fatalf("expected third response to be '%d' error with 'nil' ID", InvalidRequestCode)
 	}
 
-	if result.Error.Code != NotImplementedCode {
-		t.Fatalf("expected Error Code to be '%d'", NotImplementedCode)
+	if results[3].ID != "5" || results[3].Error == nil || results[3].Error.Code != MethodNotFoundCode {
+		t.Fatalf("expected fourth response to be '%d' error with ID '5'", MethodNotFoundCode)
 	}
 
-	if result.Error.Message != NotImplementedMessage {
-		t.Fatalf("expected Error Message to be '%s'", NotImplementedMessage)
+	if results[4].ID != "6" || results[4].Error == nil || results[4].Error.Code != InvalidMethodCode {
+		t.Fatalf("expected fifth response to be '%d' error with ID '6'", InvalidMethodCode)
 	}
 }
 
@@ -1502,16 +1552,16 @@ func TestBatchEmptyArray(t *testing.T) {
 		t.Fatal("expected Error to be not 'nil'")
 	}
 
-	if result.Error.Code != NotImplementedCode {
-		t.Fatalf("expected Error Code to be '%d'", NotImplementedCode)
+	if result.Error.Code != InvalidRequestCode {
+		t.Fatalf("expected Error Code to be '%d'", InvalidRequestCode)
 	}
 
-	if result.Error.Message != NotImplementedMessage {
-		t.Fatalf("expected Error Message to be '%s'", NotImplementedMessage)
+	if result.Error.Message != InvalidRequestMessage {
+		t.Fatalf("expected Error Message to be '%s'", InvalidRequestMessage)
 	}
 
-	if result.Error.Data != "batch requests not supported" {
-		t.Fatal("expected data to be 'batch requests not supported'")
+	if result.Error.Data != "batch request must not be empty" {
+		t.Fatal("expected data to be 'batch request must not be empty'")
 	}
 }
 
@@ -1540,8 +1590,30 @@ func TestInvalidBatch(t *testing.T) {
 	}
 
 	err = json.NewDecoder(bufio.NewReader(resp.Body)).Decode(&results)
-	if err == nil {
-		t.Fatal("expected decoder error for batch request")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected '2' responses, got '%d'", len(results))
+	}
+
+	for _, el := range results {
+		if el.ID != nil {
+			t.Fatal("expected ID to be 'nil'")
+		}
+
+		if el.Error == nil {
+			t.Fatal("expected Error to be not 'nil'")
+		}
+
+		if el.Error.Code != InvalidRequestCode {
+			t.Fatalf("expected Error Code to be '%d'", InvalidRequestCode)
+		}
+
+		if el.Error.Message != InvalidRequestMessage {
+			t.Fatalf("expected Error Message to be '%s'", InvalidRequestMessage)
+		}
 	}
 }
 
@@ -1,6 +1,7 @@
 package jrpc2
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -8,16 +9,65 @@ import (
 	"syscall"
 )
 
-// Start binds the RPCHandler to the server route and starts the HTTP server over Unix Socket.
-func (s *Service) Start() error {
-	var rerr error
+// newHTTPServer creates HTTP server that is owned by service, returns error when service was shut down.
+// Secure flag defines that server is started with TLS.
+func (s *Service) newHTTPServer(secure bool) (*http.Server, error) {
+	s.Lock()
+	defer s.Unlock()
 
-	if s.socket == nil {
-		return fmt.Errorf("unix socket must be defined")
+	if s.shutdown {
+		return nil, http.ErrServerClosed
+	}
+
+	s.server = &http.Server{
+		Handler: s.handler(),
+
+		ReadHeaderTimeout: s.readHeaderTimeout,
+		ReadTimeout:       s.readTimeout,
+		WriteTimeout:      s.writeTimeout,
+		IdleTimeout:       s.idleTimeout,
+		MaxHeaderBytes:    s.maxHeaderBytes,
+	}
+
+	s.configureHTTP2(s.server, secure)
+
+	return s.server, nil
+}
+
+// serveListener serves HTTP requests on provided listener, with TLS when certificate is provided.
+// Returns nil after graceful shutdown.
+func (s *Service) serveListener(l net.Listener, cert, key string) error {
+	secure := cert != "" || key != ""
+
+	srv, err := s.newHTTPServer(secure)
+	if err != nil {
+		return l.Close()
 	}
 
-	if s.address != nil {
-		return fmt.Errorf("network address must not be defined")
+	if secure {
+		err = srv.ServeTLS(l, cert, key)
+	} else {
+		err = srv.Serve(l)
+	}
+
+	if err != nil && err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
+
+// ServeListener binds the RPCHandler to the server route and starts the HTTP server on provided listener.
+// Listener is closed on return, returns nil after graceful shutdown.
+func (s *Service) ServeListener(l net.Listener) error {
+	return s.serveListener(l, "", "")
+}
+
+// Start binds the RPCHandler to the server route and starts the HTTP server over Unix Socket.
+// Returns nil after graceful shutdown, unix socket file is removed.
+func (s *Service) Start() (rerr error) {
+	if s.socket == nil {
+		return fmt.Errorf("unix socket must be defined")
 	}
 
 	if _, err := os.Stat(*s.socket); !os.IsNotExist(err) {
@@ -31,39 +81,47 @@ func (s *Service) Start() error {
 		return err
 	}
 
+	// listener is closed by HTTP server, unix socket file is removed on return
+	defer func() {
+		if err := os.Remove(*s.socket); err != nil && !os.IsNotExist(err) && rerr == nil {
+			rerr = err
+		}
+	}()
+
 	if err = os.Chmod(
 		*s.socket,
 		os.FileMode(s.socketMode),
 	); err != nil {
+		_ = us.Close()
+
 		return err
 	}
 
-	mux := http.NewServeMux()
-	mux.Handle(s.route, s)
+	return s.serveListener(us, "", "")
+}
 
-	defer func() {
-		if err = us.Close(); err != nil {
-			rerr = err
-		}
-	}()
+// StartTCP binds the RPCHandler to the server route and starts the HTTP server over TCP without TLS.
+// Returns nil after graceful shutdown.
+func (s *Service) StartTCP() error {
+	if s.address == nil {
+		return fmt.Errorf("network address must be defined")
+	}
 
-	if err = http.Serve(us, mux); err != nil {
+	l, err := net.Listen("tcp", *s.address)
+	if err != nil {
 		return err
 	}
 
-	return rerr
+	return s.serveListener(l, "", "")
 }
 
 // StartTCPTLS binds the RPCHandler to the server route and starts the HTTP server over TCP.
+// Returns nil after graceful shutdown.
 func (s *Service) StartTCPTLS() error {
 	if s.address == nil {
 		return fmt.Errorf("network address must be defined")
 	}
 
-	if s.socket != nil {
-		return fmt.Errorf("unix socket must not be defined")
-	}
-
 	if _, err := os.Stat(s.cert); os.IsNotExist(err) {
 		return fmt.Errorf("certificate file must exists")
 	}
@@ -72,8 +130,81 @@ func (s *Service) StartTCPTLS() error {
 		return fmt.Errorf("certificate key file must exists")
 	}
 
-	mux := http.NewServeMux()
-	mux.Handle(s.route, s)
+	l, err := net.Listen("tcp", *s.address)
+	if err != nil {
+		return err
+	}
+
+	return s.serveListener(l, s.cert, s.key)
+}
+
+// Serve starts the HTTP server over Unix Socket, over TCP with TLS (when certificate is defined) or
+// over plain TCP, depending on service configuration, server is gracefully shut down (see Shutdown)
+// when provided context is done.
+func (s *Service) Serve(ctx context.Context) error {
+	errCh := make(chan error, 1)
+
+	go func() {
+		switch {
+		case s.socket != nil:
+			errCh <- s.Start()
+		case s.cert != "" || s.key != "":
+			errCh <- s.StartTCPTLS()
+		default:
+			errCh <- s.StartTCP()
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.GetShutdownTimeout())
+	defer cancel()
+
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	return <-errCh
+}
+
+// Shutdown gracefully shuts down the HTTP server, it stops accepting new connections
+// and waits for active requests to finish until provided context is done.
+// Persistent connections (WebSocket, stream) discard new messages and are closed when
+// in-flight messages are processed, or when provided context is done.
+// Additional endpoints are shut down too. Service can not be started again after shutdown.
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.Lock()
+	s.shutdown = true
+	srv := s.server
+
+	endpoints := make([]*Service, 0, len(s.endpoints))
+	for _, el := range s.endpoints {
+		endpoints = append(endpoints, el)
+	}
+	s.Unlock()
+
+	// stream listeners and persistent connections are not tracked by HTTP server
+	s.closeListeners()
+	err := s.closeConns(ctx)
+
+	// endpoints are served by the same HTTP server, only their connections must be closed
+	for _, el := range endpoints {
+		if eerr := el.Shutdown(ctx); eerr != nil && err == nil {
+			err = eerr
+		}
+	}
+
+	if srv == nil {
+		return err
+	}
+
+	if serr := srv.Shutdown(ctx); serr != nil {
+		return serr
+	}
 
-	return http.ListenAndServeTLS(*s.address, s.cert, s.key, mux)
+	return err
 }
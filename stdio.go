@@ -0,0 +1,39 @@
+package jrpc2
+
+import (
+	"io"
+	"os"
+)
+
+// stdio joins reader and writer into single stream, closing stream closes reader when it is closable.
+type stdio struct {
+	io.Reader
+	io.Writer
+}
+
+// Close closes reader side of stream.
+func (s stdio) Close() error {
+	if c, ok := s.Reader.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// ServeIO serves JSON-RPC 2.0 messages read from r, responses and notifications are written to w.
+// Returns nil when r reaches EOF and all responses are written or when service is shut down.
+// Service with authorization (see AddAuthorization) is not served, stream carries no credentials.
+func (s *Service) ServeIO(r io.Reader, w io.Writer, framing Framing) error {
+	err := s.serveStream(stdio{r, w}, framing, "stdio")
+	if err != nil && s.isShutdown() {
+		return nil
+	}
+
+	return err
+}
+
+// ServeStdio serves JSON-RPC 2.0 over standard input and standard output, (e.g. for service running as subprocess).
+// Standard output must not be used for anything else while serving.
+func (s *Service) ServeStdio(framing Framing) error {
+	return s.ServeIO(os.Stdin, os.Stdout, framing)
+}
@@ -0,0 +1,330 @@
+package jrpc2
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strconv"
+)
+
+/*
+  Specification URLs:
+    - https://microsoft.github.io/language-server-protocol/specifications/specification-current/#baseProtocol
+*/
+
+// Framing defines how JSON-RPC 2.0 messages are delimited in byte stream.
+type Framing int
+
+const (
+	// NewlineFraming delimits messages with newline character, (newline-delimited JSON).
+	NewlineFraming Framing = iota
+	// ContentLengthFraming prefixes messages with Content-Length header, (LSP-style base protocol).
+	ContentLengthFraming
+)
+
+// errMessageTooLarge is returned by stream codec for message that exceeds size limit, message is discarded.
+var errMessageTooLarge = errors.New("message is too large")
+
+// streamCodec reads and writes framed messages.
+type streamCodec interface {
+	ReadMessage() ([]byte, error)
+	WriteMessage(data []byte) error
+}
+
+// newStreamCodec creates codec for provided framing, limit defines maximum message size, 0 disables limit.
+func newStreamCodec(rw io.ReadWriter, framing Framing, limit int64) (streamCodec, error) {
+	switch framing {
+	case NewlineFraming:
+		return &newlineCodec{r: bufio.NewReader(rw), w: rw, limit: limit}, nil
+	case ContentLengthFraming:
+		return &contentLengthCodec{r: bufio.NewReader(rw), w: rw, limit: limit}, nil
+	default:
+		return nil, fmt.Errorf("unknown framing '%d'", framing)
+	}
+}
+
+// newlineCodec implements newline-delimited JSON framing.
+type newlineCodec struct {
+	r     *bufio.Reader
+	w     io.Writer
+	limit int64
+}
+
+// ReadMessage reads next non-empty line.
+func (c *newlineCodec) ReadMessage() ([]byte, error) {
+	for {
+		var (
+			line    []byte
+			discard bool
+		)
+
+		for {
+			part, err := c.r.ReadSlice('\n')
+
+			if !discard {
+				line = append(line, part...)
+			}
+
+			// line exceeds size limit (with line ending), rest of it is discarded
+			if c.limit > 0 && int64(len(line)) > c.limit+2 {
+				line = nil
+				discard = true
+			}
+
+			// line is longer than read buffer
+			if err == bufio.ErrBufferFull {
+				continue
+			}
+
+			// last message without trailing newline
+			if err == io.EOF && (discard || len(bytes.TrimSpace(line)) > 0) {
+				break
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			break
+		}
+
+		line = bytes.TrimSpace(line)
+
+		if discard || (c.limit > 0 && int64(len(line)) > c.limit) {
+			return nil, errMessageTooLarge
+		}
+
+		if len(line) > 0 {
+			return line, nil
+		}
+	}
+}
+
+// WriteMessage writes message followed by newline.
+func (c *newlineCodec) WriteMessage(data []byte) error {
+	_, err := c.w.Write(append(data, '\n'))
+
+	return err
+}
+
+// contentLengthCodec implements Content-Length header framing.
+type contentLengthCodec struct {
+	r     *bufio.Reader
+	w     io.Writer
+	limit int64
+}
+
+// ReadMessage reads message headers and message content.
+func (c *contentLengthCodec) ReadMessage() ([]byte, error) {
+	header, err := textproto.NewReader(c.r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header '%s'", header.Get("Content-Length"))
+	}
+
+	// message exceeds size limit, its content is discarded
+	if c.limit > 0 && length > c.limit {
+		if _, err = io.CopyN(ioutil.Discard, c.r, length); err != nil {
+			return nil, err
+		}
+
+		return nil, errMessageTooLarge
+	}
+
+	data := make([]byte, length)
+
+	if _, err = io.ReadFull(c.r, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// WriteMessage writes Content-Length header followed by message.
+func (c *contentLengthCodec) WriteMessage(data []byte) error {
+	_, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+
+	return err
+}
+
+// streamRequest creates HTTP request placeholder that carries connection context and remote address,
+// it keeps parameters object methods usable for stream transports.
+func (s *Service) streamRequest(ctx context.Context, remoteAddr string) *http.Request {
+	r, _ := http.NewRequest("", "", nil) // nolint: errcheck
+
+	r.RemoteAddr = remoteAddr
+
+	r = s.setRequestContextEarly(r.WithContext(ctx))
+
+	// stream connections do not pass reverse proxy headers
+	return r.WithContext(contextWithBehindReverseProxyFlag(r.Context(), false))
+}
+
+// checkStreamAuthorization returns error when service has authorization, stream connections carry no credentials.
+func (s *Service) checkStreamAuthorization() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.auth != nil {
+		return fmt.Errorf("authorization is not supported by stream transport")
+	}
+
+	return nil
+}
+
+// serveStream serves JSON-RPC 2.0 messages from byte stream until it is closed or service is shut down.
+// Messages are invoked concurrently (pipelining), responses are written as soon as they are ready.
+func (s *Service) serveStream(rwc io.ReadWriteCloser, framing Framing, remoteAddr string) error {
+	// authorization can not be checked without HTTP headers
+	if err := s.checkStreamAuthorization(); err != nil {
+		_ = rwc.Close()
+
+		return err
+	}
+
+	codec, err := newStreamCodec(rwc, framing, s.GetMaxRequestSize())
+	if err != nil {
+		return err
+	}
+
+	c := newConn(context.Background(), codec.WriteMessage, rwc.Close, s.GetConnConcurrency())
+
+	// service was shut down
+	if !s.trackConn(c) {
+		return c.Close()
+	}
+
+	defer s.untrackConn(c)
+
+	// method context carries connection
+	r := s.streamRequest(contextWithConn(c.Context(), c), remoteAddr)
+
+	for {
+		data, err := codec.ReadMessage()
+		if err == errMessageTooLarge {
+			_ = c.Write(errorResponse(&ErrorObject{
+				Code:    InvalidRequestCode,
+				Message: InvalidRequestMessage,
+				Data:    fmt.Sprintf("message must not exceed %d bytes", s.GetMaxRequestSize()),
+			}))
+
+			continue
+		}
+
+		if err == io.EOF { // client finished sending, wait for responses
+			<-c.drain()
+
+			return c.Close()
+		}
+
+		if err != nil { // connection failed or framing error
+			_ = c.Close()
+
+			<-c.drain()
+
+			return err
+		}
+
+		// requests are invoked concurrently, responses can be sent out of order,
+		// messages received during shutdown are discarded
+		c.dispatch(func() {
+			s.handleConnMessage(c, r, data)
+		})
+	}
+}
+
+// ServeStream serves JSON-RPC 2.0 over raw stream connections (TCP or unix socket) accepted on provided listener,
+// without HTTP. Each connection supports pipelining, concurrent responses, server notifications and subscriptions.
+// Stream connections carry no credentials, service with authorization (see AddAuthorization) is not served.
+// Listener is closed on return, returns nil after graceful shutdown.
+func (s *Service) ServeStream(l net.Listener, framing Framing) error {
+	if framing != NewlineFraming && framing != ContentLengthFraming {
+		return fmt.Errorf("unknown framing '%d'", framing)
+	}
+
+	// authorization can not be checked without HTTP headers
+	if err := s.checkStreamAuthorization(); err != nil {
+		_ = l.Close()
+
+		return err
+	}
+
+	// service was shut down
+	if !s.trackListener(l) {
+		return l.Close()
+	}
+
+	defer s.untrackListener(l)
+
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			if s.isShutdown() {
+				return nil
+			}
+
+			return err
+		}
+
+		go func() {
+			_ = s.serveStream(nc, framing, nc.RemoteAddr().String())
+		}()
+	}
+}
+
+// trackListener adds listener to the list of service listeners that are closed on shutdown.
+// Returns false when service was shut down.
+func (s *Service) trackListener(l net.Listener) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.shutdown {
+		return false
+	}
+
+	if s.listeners == nil {
+		s.listeners = make(map[net.Listener]struct{})
+	}
+
+	s.listeners[l] = struct{}{}
+
+	return true
+}
+
+// untrackListener removes listener from the list of service listeners and closes it.
+func (s *Service) untrackListener(l net.Listener) {
+	s.Lock()
+	delete(s.listeners, l)
+	s.Unlock()
+
+	_ = l.Close()
+}
+
+// closeListeners closes all service listeners.
+func (s *Service) closeListeners() {
+	s.Lock()
+	defer s.Unlock()
+
+	for l := range s.listeners {
+		_ = l.Close()
+	}
+}
+
+// isShutdown returns true when service was shut down.
+func (s *Service) isShutdown() bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.shutdown
+}
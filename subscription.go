@@ -0,0 +1,186 @@
+package jrpc2
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+// SubscriptionNotificationMethod specifies method name of notifications that carry subscription events.
+const SubscriptionNotificationMethod string = "subscription"
+
+// SubscriptionFunc starts subscription, returns channel of events that are pushed to client.
+// Context is cancelled on unsubscribe or when connection is closed, subscription ends when channel is closed.
+type SubscriptionFunc func(ctx context.Context, params ParametersObject) (<-chan interface{}, *ErrorObject)
+
+// SubscriptionNotification represents params of subscription event notification.
+type SubscriptionNotification struct {
+	// Subscription contains subscription ID returned by subscribe method
+	Subscription string `json:"subscription"`
+	// Result contains subscription event
+	Result interface{} `json:"result"`
+}
+
+// pendingSubscriptions collects subscriptions started while processing single message,
+// they are activated after response is sent so client receives subscription ID before first event.
+type pendingSubscriptions struct {
+	mu     sync.Mutex
+	starts []func()
+}
+
+// add appends subscription start function.
+func (p *pendingSubscriptions) add(start func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.starts = append(p.starts, start)
+}
+
+// activate runs collected subscription start functions.
+func (p *pendingSubscriptions) activate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, start := range p.starts {
+		start()
+	}
+
+	p.starts = nil
+}
+
+// newSubscriptionID generates random subscription ID.
+func newSubscriptionID() string {
+	b := make([]byte, 16)
+
+	// error is ignored, crypto/rand does not fail on supported platforms
+	_, _ = rand.Read(b)
+
+	return "0x" + hex.EncodeToString(b)
+}
+
+// RegisterSubscription maps subscribe method name to subscription function and unsubscribe method name to
+// subscription cancellation, subscriptions require persistent connection (e.g. WebSocket).
+// Subscribe method returns subscription ID, events are pushed as "subscription" notifications,
+// unsubscribe method accepts subscription ID as single positional param and returns true when subscription existed.
+// Unsubscribe method can be shared between subscriptions.
+func (s *Service) RegisterSubscription(name, unsubscribe string, f SubscriptionFunc) {
+	s.Register(name, func(params ParametersObject) (interface{}, *ErrorObject) {
+		c := params.GetConn()
+		if c == nil {
+			return nil, &ErrorObject{
+				Code:    NotImplementedCode,
+				Message: NotImplementedMessage,
+				Data:    "subscriptions require persistent connection",
+			}
+		}
+
+		// subscription outlives method call, it is bound to connection
+		ctx, cancel := context.WithCancel(c.Context())
+
+		events, errObj := f(ctx, params)
+		if errObj != nil {
+			cancel()
+
+			return nil, errObj
+		}
+
+		id := newSubscriptionID()
+
+		c.addSubscription(id, cancel)
+
+		start := func() {
+			go c.pushEvents(ctx, id, events)
+		}
+
+		// wait for response with subscription ID
+		if pending := pendingSubscriptionsFromContext(params.GetContext()); pending != nil {
+			pending.add(start)
+		} else {
+			start()
+		}
+
+		return id, nil
+	})
+
+	s.Register(unsubscribe, func(params ParametersObject) (interface{}, *ErrorObject) {
+		id, errObj := GetPositionalStringParams(params)
+		if errObj != nil {
+			return nil, errObj
+		}
+
+		if len(id) != 1 {
+			return nil, &ErrorObject{
+				Code:    InvalidParamsCode,
+				Message: InvalidParamsMessage,
+				Data:    "exactly one subscription ID is expected",
+			}
+		}
+
+		c := params.GetConn()
+		if c == nil {
+			return false, nil
+		}
+
+		return c.cancelSubscription(id[0]), nil
+	})
+}
+
+// addSubscription stores subscription cancellation function.
+func (c *Conn) addSubscription(id string, cancel context.CancelFunc) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	if c.subs == nil {
+		c.subs = make(map[string]context.CancelFunc)
+	}
+
+	c.subs[id] = cancel
+}
+
+// cancelSubscription cancels subscription, returns false when subscription does not exist.
+func (c *Conn) cancelSubscription(id string) bool {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	cancel, ok := c.subs[id]
+	if !ok {
+		return false
+	}
+
+	cancel()
+	delete(c.subs, id)
+
+	return true
+}
+
+// GetSubscriptions returns number of active subscriptions of connection.
+func (c *Conn) GetSubscriptions() int {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	return len(c.subs)
+}
+
+// pushEvents sends subscription events to client until subscription is cancelled or events channel is closed.
+func (c *Conn) pushEvents(ctx context.Context, id string, events <-chan interface{}) {
+	defer c.cancelSubscription(id)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if err := c.Notify(SubscriptionNotificationMethod, SubscriptionNotification{
+				Subscription: id,
+				Result:       event,
+			}); err != nil {
+				return
+			}
+		}
+	}
+}
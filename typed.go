@@ -0,0 +1,189 @@
+package jrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// nolint: gochecknoglobals
+var (
+	typeOfContext = reflect.TypeOf((*context.Context)(nil)).Elem()
+	typeOfError   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// typedMethod builds method from typed function, see RegisterTyped for allowed signatures.
+func typedMethod(fv reflect.Value) (method, error) {
+	if !fv.IsValid() || fv.Kind() != reflect.Func {
+		return method{}, fmt.Errorf("method must be a function")
+	}
+
+	ft := fv.Type()
+
+	// validate function input
+	withContext := ft.NumIn() == 2 && ft.In(0) == typeOfContext
+
+	if ft.NumIn() != 1 && !withContext {
+		return method{}, fmt.Errorf("method '%s' must accept Args or context.Context and Args", ft)
+	}
+
+	// validate function output
+	if ft.NumOut() != 2 || ft.Out(1) != typeOfError {
+		return method{}, fmt.Errorf("method '%s' must return Reply and error", ft)
+	}
+
+	argType := ft.In(ft.NumIn() - 1)
+
+	return method{
+		argType:   argType,
+		replyType: ft.Out(0),
+
+		ContextMethod: func(ctx context.Context, data ParametersObject) (interface{}, *ErrorObject) {
+			// decode params to Args
+			arg, errObj := decodeTypedParams(data.GetRawJSONParams(), argType)
+			if errObj != nil {
+				return nil, errObj
+			}
+
+			in := []reflect.Value{arg}
+			if withContext {
+				in = []reflect.Value{reflect.ValueOf(ctx), arg}
+			}
+
+			out := fv.Call(in)
+
+			// method failed
+			if err, _ := out[1].Interface().(error); err != nil {
+				return nil, typedError(err)
+			}
+
+			// nil pointer Reply
+			if out[0].Kind() == reflect.Ptr && out[0].IsNil() {
+				return nil, nil
+			}
+
+			return out[0].Interface(), nil
+		},
+	}, nil
+}
+
+// typedError converts error returned by typed function to error object.
+func typedError(err error) *ErrorObject {
+	if errObj, ok := err.(*ErrorObject); ok {
+		return errObj
+	}
+
+	return &ErrorObject{
+		Code:    InternalErrorCode,
+		Message: InternalErrorMessage,
+		Data:    err.Error(),
+	}
+}
+
+// positionalFields returns indexes of struct fields that are filled from positional params.
+func positionalFields(t reflect.Type) []int {
+	out := make([]int, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		// skip unexported and ignored fields
+		if field.PkgPath != "" || field.Tag.Get("json") == "-" {
+			continue
+		}
+
+		out = append(out, i)
+	}
+
+	return out
+}
+
+// decodeTypedParams decodes named (object) or positional (array) raw JSON params to value of provided type.
+func decodeTypedParams(params json.RawMessage, t reflect.Type) (reflect.Value, *ErrorObject) {
+	base := t
+	if t.Kind() == reflect.Ptr {
+		base = t.Elem()
+	}
+
+	v := reflect.New(base)
+
+	params = bytes.TrimSpace(params)
+
+	switch {
+	// params are omitted, use zero value
+	case len(params) == 0 || bytes.Equal(params, []byte("null")):
+	// positional params
+	case params[0] == '[' && base.Kind() != reflect.Slice && base.Kind() != reflect.Array && base.Kind() != reflect.Interface:
+		positional := make([]json.RawMessage, 0)
+
+		if errObj := unmarshalParams(params, &positional); errObj != nil {
+			return reflect.Value{}, errObj
+		}
+
+		if base.Kind() != reflect.Struct {
+			if len(positional) != 1 {
+				return reflect.Value{}, &ErrorObject{
+					Code:    InvalidParamsCode,
+					Message: InvalidParamsMessage,
+					Data:    "exactly one positional param is required",
+				}
+			}
+
+			if errObj := unmarshalParams(positional[0], v.Interface()); errObj != nil {
+				return reflect.Value{}, errObj
+			}
+
+			break
+		}
+
+		fields := positionalFields(base)
+
+		if len(positional) > len(fields) {
+			return reflect.Value{}, &ErrorObject{
+				Code:    InvalidParamsCode,
+				Message: InvalidParamsMessage,
+				Data:    fmt.Sprintf("at most %d positional params are allowed", len(fields)),
+			}
+		}
+
+		// fill struct fields in declaration order
+		for i, el := range positional {
+			if errObj := unmarshalParams(el, v.Elem().Field(fields[i]).Addr().Interface()); errObj != nil {
+				return reflect.Value{}, errObj
+			}
+		}
+	// named params
+	default:
+		if errObj := unmarshalParams(params, v.Interface()); errObj != nil {
+			return reflect.Value{}, errObj
+		}
+	}
+
+	if t.Kind() == reflect.Ptr {
+		return v, nil
+	}
+
+	return v.Elem(), nil
+}
+
+// RegisterTyped maps the provided method name to the given typed function for later method calls.
+// Function must have one of the following signatures, where Args and Reply are JSON (de)serializable types:
+//
+//	func(Args) (Reply, error)
+//	func(context.Context, Args) (Reply, error)
+//
+// Args and Reply can also be pointers. Named params are decoded into Args, positional params fill
+// exported Args struct fields in declaration order, decoding failure is reported as Invalid params.
+// Returned *ErrorObject is sent to client as is, any other error is reported as Internal error.
+func (s *Service) RegisterTyped(name string, f interface{}) error {
+	m, err := typedMethod(reflect.ValueOf(f))
+	if err != nil {
+		return err
+	}
+
+	s.register(name, m)
+
+	return nil
+}
@@ -8,24 +8,36 @@ import (
 
 // ValidateHTTPProtocolVersion validates HTTP protocol version.
 func (responseObject *ResponseObject) ValidateHTTPProtocolVersion(r *http.Request) bool {
+	return responseObject.ValidateHTTPProtocolVersions(r, []string{DefaultHTTPVersion})
+}
+
+// ValidateHTTPProtocolVersions validates HTTP protocol version against list of accepted versions, (e.g. "HTTP/1.1").
+func (responseObject *ResponseObject) ValidateHTTPProtocolVersions(r *http.Request, versions []string) bool {
 	// check request protocol version
-	if r.Proto != "HTTP/1.1" { // nolint: goconst
-		responseObject.Error = &ErrorObject{
-			Code:    InvalidRequestCode,
-			Message: InvalidRequestMessage,
-			Data:    "request protocol version must be HTTP/1.1",
+	for _, el := range versions {
+		if r.Proto == el {
+			return true
 		}
+	}
 
-		// set Response status code to 501 (not implemented)
-		r = setHTTPStatusCode(r, http.StatusNotImplemented)
-
-		// set pointer to HTTP request object
-		responseObject.r = r
+	responseObject.Error = &ErrorObject{
+		Code:    InvalidRequestCode,
+		Message: InvalidRequestMessage,
+		Data:    "request protocol version must be HTTP/1.1",
+	}
 
-		return false
+	// list accepted versions
+	if len(versions) != 1 || versions[0] != DefaultHTTPVersion {
+		responseObject.Error.Data = fmt.Sprintf("request protocol version must be one of %s", strings.Join(versions, ", "))
 	}
 
-	return true
+	// set Response status code to 501 (not implemented)
+	r = setHTTPStatusCode(r, http.StatusNotImplemented)
+
+	// set pointer to HTTP request object
+	responseObject.r = r
+
+	return false
 }
 
 // ValidateHTTPRequestMethod validates HTTP request method.
@@ -0,0 +1,423 @@
+package jrpc2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+  Specification URLs:
+    - https://json-schema.org/specification-links.html#draft-7
+*/
+
+// SchemaViolation describes single params JSON Schema validation failure.
+type SchemaViolation struct {
+	// Pointer contains JSON pointer to the failing params member
+	Pointer string `json:"pointer"`
+	// Reason contains description of the failure
+	Reason string `json:"reason"`
+}
+
+// paramsValidator validates raw JSON params against JSON Schema (draft-07 subset, no $ref support),
+// unsupported assertion keywords are rejected when schema is compiled.
+type paramsValidator struct {
+	root     interface{}               // decoded JSON Schema
+	patterns map[string]*regexp.Regexp // compiled "pattern" keywords
+}
+
+// newParamsValidator compiles JSON Schema, returns error for malformed or unsupported schema.
+func newParamsValidator(schema []byte) (*paramsValidator, error) {
+	v := &paramsValidator{
+		patterns: make(map[string]*regexp.Regexp),
+	}
+
+	if err := json.Unmarshal(schema, &v.root); err != nil {
+		return nil, fmt.Errorf("invalid JSON Schema: %w", err)
+	}
+
+	if err := v.compile(v.root, ""); err != nil {
+		return nil, fmt.Errorf("invalid JSON Schema: %w", err)
+	}
+
+	return v, nil
+}
+
+// compile checks schema keywords and precompiles patterns.
+// nolint: gocyclo
+func (v *paramsValidator) compile(schema interface{}, path string) error {
+	switch node := schema.(type) {
+	case bool:
+		return nil
+	case map[string]interface{}:
+		for key, value := range node {
+			switch key {
+			case "$ref", "multipleOf", "uniqueItems", "contains", "patternProperties", "propertyNames",
+				"dependencies", "if", "then", "else":
+				return fmt.Errorf("%s: keyword '%s' is not supported", path, key)
+			case "pattern":
+				pattern, ok := value.(string)
+				if !ok {
+					return fmt.Errorf("%s: keyword 'pattern' must be a string", path)
+				}
+
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+
+				v.patterns[pattern] = re
+			case "type":
+				if _, ok := value.(string); ok {
+					continue
+				}
+
+				if _, ok := value.([]interface{}); !ok {
+					return fmt.Errorf("%s: keyword 'type' must be a string or an array", path)
+				}
+			case "properties":
+				properties, ok := value.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("%s: keyword 'properties' must be an object", path)
+				}
+
+				for name, el := range properties {
+					if err := v.compile(el, path+"/properties/"+name); err != nil {
+						return err
+					}
+				}
+			case "items":
+				if items, ok := value.([]interface{}); ok {
+					for i, el := range items {
+						if err := v.compile(el, fmt.Sprintf("%s/items/%d", path, i)); err != nil {
+							return err
+						}
+					}
+
+					continue
+				}
+
+				if err := v.compile(value, path+"/items"); err != nil {
+					return err
+				}
+			case "additionalProperties", "additionalItems", "not":
+				if err := v.compile(value, path+"/"+key); err != nil {
+					return err
+				}
+			case "allOf", "anyOf", "oneOf":
+				list, ok := value.([]interface{})
+				if !ok {
+					return fmt.Errorf("%s: keyword '%s' must be an array", path, key)
+				}
+
+				for i, el := range list {
+					if err := v.compile(el, fmt.Sprintf("%s/%s/%d", path, key, i)); err != nil {
+						return err
+					}
+				}
+			case "required":
+				if _, ok := value.([]interface{}); !ok {
+					return fmt.Errorf("%s: keyword 'required' must be an array", path)
+				}
+			case "enum":
+				if _, ok := value.([]interface{}); !ok {
+					return fmt.Errorf("%s: keyword 'enum' must be an array", path)
+				}
+			case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum",
+				"minLength", "maxLength", "minItems", "maxItems", "minProperties", "maxProperties":
+				if _, ok := value.(float64); !ok {
+					return fmt.Errorf("%s: keyword '%s' must be a number", path, key)
+				}
+			}
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("%s: schema must be an object or a boolean", path)
+	}
+}
+
+// Validate validates raw JSON params, returns list of violations, empty list for valid params.
+func (v *paramsValidator) Validate(params json.RawMessage) []SchemaViolation {
+	var value interface{}
+
+	// omitted params are validated as null
+	if len(bytes.TrimSpace(params)) != 0 {
+		if err := json.Unmarshal(params, &value); err != nil {
+			return []SchemaViolation{
+				{
+					Pointer: "",
+					Reason:  err.Error(),
+				},
+			}
+		}
+	}
+
+	out := make([]SchemaViolation, 0)
+
+	v.validate(v.root, value, "", &out)
+
+	return out
+}
+
+// escapePointer escapes JSON pointer reference token.
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// jsonTypeOf returns JSON Schema type name of decoded JSON value.
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if math.Trunc(v) == v {
+			return "integer"
+		}
+
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// matchesType checks that decoded JSON value matches JSON Schema type name.
+func matchesType(value interface{}, typeName string) bool {
+	actual := jsonTypeOf(value)
+
+	return actual == typeName || (typeName == "number" && actual == "integer")
+}
+
+// nolint: gocyclo
+func (v *paramsValidator) validate(schema, value interface{}, pointer string, out *[]SchemaViolation) {
+	fail := func(format string, args ...interface{}) {
+		*out = append(*out, SchemaViolation{
+			Pointer: pointer,
+			Reason:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	node, ok := schema.(map[string]interface{})
+	if !ok {
+		if schema == false {
+			fail("value is not allowed")
+		}
+
+		return
+	}
+
+	// type
+	switch t := node["type"].(type) {
+	case string:
+		if !matchesType(value, t) {
+			fail("expected %s, got %s", t, jsonTypeOf(value))
+
+			return
+		}
+	case []interface{}:
+		names := make([]string, 0, len(t))
+		matched := false
+
+		for _, el := range t {
+			name, _ := el.(string)
+			names = append(names, name)
+
+			if matchesType(value, name) {
+				matched = true
+			}
+		}
+
+		if !matched {
+			fail("expected one of %s, got %s", strings.Join(names, ", "), jsonTypeOf(value))
+
+			return
+		}
+	}
+
+	// enum and const
+	if enum, ok := node["enum"].([]interface{}); ok {
+		found := false
+
+		for _, el := range enum {
+			if reflect.DeepEqual(el, value) {
+				found = true
+			}
+		}
+
+		if !found {
+			fail("value is not one of enumerated values")
+		}
+	}
+
+	if c, ok := node["const"]; ok && !reflect.DeepEqual(c, value) {
+		fail("value must be equal to constant")
+	}
+
+	// combinators
+	if list, ok := node["allOf"].([]interface{}); ok {
+		for _, el := range list {
+			v.validate(el, value, pointer, out)
+		}
+	}
+
+	if list, ok := node["anyOf"].([]interface{}); ok {
+		matched := false
+
+		for _, el := range list {
+			if v.isValid(el, value) {
+				matched = true
+			}
+		}
+
+		if !matched {
+			fail("value does not match any schema from anyOf")
+		}
+	}
+
+	if list, ok := node["oneOf"].([]interface{}); ok {
+		matched := 0
+
+		for _, el := range list {
+			if v.isValid(el, value) {
+				matched++
+			}
+		}
+
+		if matched != 1 {
+			fail("value must match exactly one schema from oneOf, matched %d", matched)
+		}
+	}
+
+	if not, ok := node["not"]; ok && v.isValid(not, value) {
+		fail("value must not match schema from not")
+	}
+
+	switch val := value.(type) {
+	case float64:
+		if min, ok := node["minimum"].(float64); ok && val < min {
+			fail("must be greater than or equal to %v", min)
+		}
+
+		if max, ok := node["maximum"].(float64); ok && val > max {
+			fail("must be less than or equal to %v", max)
+		}
+
+		if min, ok := node["exclusiveMinimum"].(float64); ok && val <= min {
+			fail("must be greater than %v", min)
+		}
+
+		if max, ok := node["exclusiveMaximum"].(float64); ok && val >= max {
+			fail("must be less than %v", max)
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(val))
+
+		if min, ok := node["minLength"].(float64); ok && length < min {
+			fail("length must be greater than or equal to %v", min)
+		}
+
+		if max, ok := node["maxLength"].(float64); ok && length > max {
+			fail("length must be less than or equal to %v", max)
+		}
+
+		if pattern, ok := node["pattern"].(string); ok && !v.patterns[pattern].MatchString(val) {
+			fail("does not match pattern '%s'", pattern)
+		}
+	case []interface{}:
+		if min, ok := node["minItems"].(float64); ok && float64(len(val)) < min {
+			fail("must contain at least %v items", min)
+		}
+
+		if max, ok := node["maxItems"].(float64); ok && float64(len(val)) > max {
+			fail("must contain at most %v items", max)
+		}
+
+		switch items := node["items"].(type) {
+		case []interface{}:
+			for i, el := range val {
+				itemPointer := fmt.Sprintf("%s/%d", pointer, i)
+
+				if i < len(items) {
+					v.validate(items[i], el, itemPointer, out)
+				} else if additional, ok := node["additionalItems"]; ok {
+					v.validate(additional, el, itemPointer, out)
+				}
+			}
+		case nil:
+		default:
+			for i, el := range val {
+				v.validate(items, el, fmt.Sprintf("%s/%d", pointer, i), out)
+			}
+		}
+	case map[string]interface{}:
+		if min, ok := node["minProperties"].(float64); ok && float64(len(val)) < min {
+			fail("must contain at least %v properties", min)
+		}
+
+		if max, ok := node["maxProperties"].(float64); ok && float64(len(val)) > max {
+			fail("must contain at most %v properties", max)
+		}
+
+		if required, ok := node["required"].([]interface{}); ok {
+			for _, el := range required {
+				name, _ := el.(string)
+
+				if _, ok := val[name]; !ok {
+					*out = append(*out, SchemaViolation{
+						Pointer: pointer + "/" + escapePointer(name),
+						Reason:  "property is required",
+					})
+				}
+			}
+		}
+
+		properties, _ := node["properties"].(map[string]interface{})
+		additional, hasAdditional := node["additionalProperties"]
+
+		// stable violations order
+		names := make([]string, 0, len(val))
+		for name := range val {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			propertyPointer := pointer + "/" + escapePointer(name)
+
+			if property, ok := properties[name]; ok {
+				v.validate(property, val[name], propertyPointer, out)
+			} else if hasAdditional {
+				if additional == false {
+					*out = append(*out, SchemaViolation{
+						Pointer: propertyPointer,
+						Reason:  "additional property is not allowed",
+					})
+				} else {
+					v.validate(additional, val[name], propertyPointer, out)
+				}
+			}
+		}
+	}
+}
+
+// isValid checks that decoded JSON value matches schema.
+func (v *paramsValidator) isValid(schema, value interface{}) bool {
+	out := make([]SchemaViolation, 0)
+
+	v.validate(schema, value, "", &out)
+
+	return len(out) == 0
+}
@@ -0,0 +1,104 @@
+package jrpc2
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+/*
+  Specification URLs:
+    - https://tools.ietf.org/html/rfc6455
+*/
+
+// SetWebSocket enables WebSocket transport on service route, WebSocket handshake requests are upgraded
+// while other requests are served as JSON-RPC 2.0 over HTTP.
+// Each WebSocket message carries single request, notification or batch, requests are invoked concurrently and
+// responses are sent as soon as they are ready, (possibly out of order), clients match them by ID.
+// Request and response hooks are not used for WebSocket messages.
+func (s *Service) SetWebSocket(flag bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.webSocket = flag
+}
+
+// GetWebSocket returns true when WebSocket transport is enabled.
+func (s *Service) GetWebSocket() bool {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.webSocket
+}
+
+// SetWebSocketOriginCheck defines function that validates WebSocket handshake Origin header,
+// by default cross-origin handshakes are rejected.
+func (s *Service) SetWebSocketOriginCheck(f func(r *http.Request) bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.wsCheckOrigin = f
+}
+
+// serveWebSocket upgrades HTTP request to WebSocket connection and serves JSON-RPC 2.0 messages until
+// connection is closed by client or service is shut down.
+func (s *Service) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	upgrader := websocket.Upgrader{
+		CheckOrigin: s.wsCheckOrigin,
+	}
+	s.Unlock()
+
+	// upgrader writes HTTP error response on failure
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	// limit size of incoming messages
+	if limit := s.GetMaxRequestSizeForRoute(r.URL.Path); limit > 0 {
+		ws.SetReadLimit(limit)
+	}
+
+	c := newConn(
+		r.Context(),
+		func(data []byte) error {
+			return ws.WriteMessage(websocket.TextMessage, data)
+		},
+		ws.Close,
+		s.GetConnConcurrency(),
+	)
+
+	// service was shut down
+	if !s.trackConn(c) {
+		_ = c.Close()
+
+		return
+	}
+
+	defer s.untrackConn(c)
+
+	// method context carries connection
+	r = r.WithContext(contextWithConn(c.Context(), c))
+
+	for {
+		mt, data, err := ws.ReadMessage()
+		if err != nil { // connection closed
+			break
+		}
+
+		if mt != websocket.TextMessage && mt != websocket.BinaryMessage {
+			continue
+		}
+
+		// requests are invoked concurrently, responses can be sent out of order
+		c.dispatch(func() {
+			s.handleConnMessage(c, r, data)
+		})
+	}
+
+	// cancel in-flight method calls
+	_ = c.Close()
+
+	<-c.drain()
+}